@@ -1,15 +1,34 @@
 package main
 
 import (
+	"flag"
+	"os"
+
 	"github.com/sirupsen/logrus"
 
 	"github.com/dink10/enlabs/info"
 	"github.com/dink10/enlabs/internal/app/processing"
 )
 
+// Usage:
+//
+//	processing                      runs cancellation cycles on schedule
+//	processing run-once [--dry-run] runs a single cancellation cycle and prints its outcome
 func main() {
 	logrus.Infof("Processing version: %s", info.Version)
 
+	if len(os.Args) > 1 && os.Args[1] == "run-once" {
+		flags := flag.NewFlagSet("run-once", flag.ExitOnError)
+		dryRun := flags.Bool("dry-run", false,
+			"print payments which would be cancelled and resulting balances without committing")
+		_ = flags.Parse(os.Args[2:])
+
+		if err := processing.RunOnce(os.Stdout, *dryRun); err != nil {
+			logrus.Fatal(err)
+		}
+		return
+	}
+
 	if err := processing.Run(); err != nil {
 		logrus.Fatal(err)
 	}
@@ -13,9 +13,9 @@ require (
 	github.com/go-pg/urlstruct v0.4.0 // indirect
 	github.com/golang/protobuf v1.4.0 // indirect
 	github.com/gookit/validate v1.2.2
-	github.com/jasonlvhit/gocron v0.0.0-20200423141508-ab84337f7963
 	github.com/mailru/easyjson v0.7.1 // indirect
 	github.com/rafaeljesus/retry-go v0.0.0-20171214204623-5981a380a879
+	github.com/robfig/cron/v3 v3.0.1
 	github.com/robinjoseph08/go-pg-migrations/v2 v2.1.0
 	github.com/rs/cors v1.7.0
 	github.com/satori/go.uuid v1.2.0
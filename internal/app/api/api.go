@@ -10,13 +10,19 @@ import (
 	"github.com/sirupsen/logrus"
 
 	"github.com/dink10/enlabs/internal/app/api/provider"
+	cycle "github.com/dink10/enlabs/internal/app/processing"
+	"github.com/dink10/enlabs/internal/pkg/auth"
 	"github.com/dink10/enlabs/internal/pkg/config"
 	"github.com/dink10/enlabs/internal/pkg/database"
 	"github.com/dink10/enlabs/internal/pkg/logger"
 	"github.com/dink10/enlabs/internal/pkg/payments"
 	"github.com/dink10/enlabs/internal/pkg/payments/storage"
+	"github.com/dink10/enlabs/internal/pkg/processing"
+	runStorage "github.com/dink10/enlabs/internal/pkg/processing/storage"
 	"github.com/dink10/enlabs/internal/pkg/router"
 	"github.com/dink10/enlabs/internal/pkg/server"
+	"github.com/dink10/enlabs/internal/pkg/signature"
+	secretStorage "github.com/dink10/enlabs/internal/pkg/signature/storage"
 )
 
 // Run runs application.
@@ -42,17 +48,39 @@ func Run() error {
 	defer database.Close(db)
 
 	paymentStorage := storage.NewPaymentStorage(db)
-	paymentService, err := payments.NewService(paymentStorage)
+	paymentService, err := payments.NewService(paymentStorage, &cfg.Payments)
 	if err != nil {
 		return fmt.Errorf("failed to init service: %v", err)
 	}
-	paymentProvider := provider.NewPaymentProvider(paymentService)
+	policy, err := processing.NewPolicy(&cfg.Processing.Policy)
+	if err != nil {
+		return fmt.Errorf("failed to configure cancellation policy: %v", err)
+	}
+	runService := processing.NewService(runStorage.NewRunStorage(db))
+
+	signatureService := signature.NewService(secretStorage.NewSecretStorage(db), &cfg.Signature)
+
+	authenticator := auth.NewAuthenticator(&cfg.Auth)
+	paymentProvider := provider.NewPaymentProvider(paymentService, signatureService, authenticator)
+	accountsProvider := provider.NewAccountsProvider(paymentService, authenticator)
+	ledgerProvider := provider.NewLedgerProvider(paymentService, authenticator)
+	sourceTypesProvider := provider.NewSourceTypesProvider(paymentService, signatureService, authenticator)
+	processingProvider := provider.NewProcessingProvider(
+		runService, cycle.NewJob(db, paymentService, runService, policy), authenticator,
+		cfg.Processing.ShutdownTimeout,
+	)
 
 	r := router.NewDefaultRouter(cfg.Server.LogRequests)
 	r.AddSubRouter("/v1", router.Routes{
-		"/payments": paymentProvider.Router(),
+		"/payments":     paymentProvider.Router(),
+		"/accounts":     accountsProvider.Router(),
+		"/ledger":       ledgerProvider.Router(),
+		"/processing":   processingProvider.Router(),
+		"/source-types": sourceTypesProvider.Router(),
 	})
 
+	go watchSourceTypes(ctx, db, paymentService, cfg.Payments.SourceTypesRefresh)
+	go purgeNonces(ctx, signatureService, cfg.Signature.MaxSkew)
 	go cancelOnSignal(cancel)
 
 	httpServer := server.New(&cfg.Server, r.Handler())
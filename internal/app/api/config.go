@@ -1,14 +1,22 @@
 package api
 
 import (
+	"github.com/dink10/enlabs/internal/pkg/auth"
 	"github.com/dink10/enlabs/internal/pkg/database"
 	"github.com/dink10/enlabs/internal/pkg/logger"
+	"github.com/dink10/enlabs/internal/pkg/payments"
+	"github.com/dink10/enlabs/internal/pkg/processing"
 	"github.com/dink10/enlabs/internal/pkg/server"
+	"github.com/dink10/enlabs/internal/pkg/signature"
 )
 
 // Config is an application config.
 type Config struct {
-	Server   server.Config
-	Logger   logger.Config
-	Database database.Config
+	Server     server.Config
+	Logger     logger.Config
+	Database   database.Config
+	Auth       auth.Config
+	Payments   payments.Config
+	Processing processing.Config
+	Signature  signature.Config
 }
@@ -0,0 +1,539 @@
+package provider
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi"
+
+	"github.com/dink10/enlabs/internal/pkg/auth"
+	"github.com/dink10/enlabs/internal/pkg/logger"
+	"github.com/dink10/enlabs/internal/pkg/payments"
+	"github.com/dink10/enlabs/internal/pkg/server"
+)
+
+// AccountsProvider provides endpoints to manage accounts.
+type AccountsProvider struct {
+	service       *payments.Service
+	authenticator *auth.Authenticator
+	logger        *logger.ProviderLogger
+}
+
+// NewAccountsProvider returns a new instance of AccountsProvider.
+func NewAccountsProvider(service *payments.Service, authenticator *auth.Authenticator) AccountsProvider {
+	return AccountsProvider{
+		service:       service,
+		authenticator: authenticator,
+		logger:        logger.NewProviderLogger("accounts"),
+	}
+}
+
+// Router returns AccountsProvider router.
+func (p *AccountsProvider) Router() http.Handler {
+	r := chi.NewRouter()
+
+	r.Route("/", func(r chi.Router) {
+		r.Use(p.authenticator.Middleware)
+		r.With(auth.RequireAdmin).Post("/", p.create)
+		r.With(auth.RequireAdmin).Get("/", p.list)
+		r.Get("/{accountID}", p.get)
+		r.With(auth.RequireAdmin).Patch("/{accountID}", p.update)
+		r.Get("/{accountID}/limits", p.limits)
+		r.Put("/{accountID}/limits", p.setLimit)
+		r.Get("/{accountID}/exclusion", p.exclusion)
+		r.Put("/{accountID}/exclusion", p.exclude)
+		r.Get("/{accountID}/exclusion/history", p.exclusionHistory)
+	})
+
+	return r
+}
+
+// Request body format for creating an account.
+type accountRequest struct {
+	Currencies []string `json:"currencies"`
+}
+
+// Request body format for changing account status.
+type accountUpdateRequest struct {
+	Status string `json:"status"`
+}
+
+// Request body format for setting an account limit.
+type limitRequest struct {
+	Kind     string `json:"kind"`
+	Currency string `json:"currency"`
+	Amount   string `json:"amount"`
+}
+
+// Request body format for self-exclusion, either for days or permanent.
+type exclusionRequest struct {
+	Days      int  `json:"days"`
+	Permanent bool `json:"permanent"`
+}
+
+type accountResponse struct {
+	*server.Response
+	Account payments.Account `json:"account"`
+}
+
+type accountsResponse struct {
+	*server.Response
+	Accounts []payments.Account `json:"accounts"`
+	Total    int                `json:"total"`
+}
+
+type limitResponse struct {
+	*server.Response
+	Limit payments.AccountLimit `json:"limit"`
+}
+
+type limitsResponse struct {
+	*server.Response
+	Limits []payments.AccountLimit `json:"limits"`
+}
+
+type exclusionResponse struct {
+	*server.Response
+	Exclusion payments.Exclusion `json:"exclusion"`
+}
+
+type exclusionHistoryResponse struct {
+	*server.Response
+	History []payments.ExclusionChange `json:"history"`
+}
+
+// @Summary Create account
+// @Description Create a new active account with zero balances in given currencies
+// @ID account-create
+// @Tags Account
+// @Accept json
+// @Produce json
+// @Param account body provider.accountRequest true "Currencies to open account in"
+// @Param Authorization header string true "Bearer token of admin"
+// @Success 201 {object} provider.accountResponse "Created account"
+// @Failure 400 {object} server.ErrorResponse "Invalid Request"
+// @Failure 401 {object} server.ErrorResponse "Unauthorized"
+// @Failure 403 {object} server.ErrorResponse "Forbidden"
+// @Failure 500 {object} server.ErrorResponse "Service Error"
+// @Router /v1/accounts [post]
+func (p *AccountsProvider) create(w http.ResponseWriter, r *http.Request) {
+	if err := checkContentType(r); err != nil {
+		p.logger.Logger(r).Error(err)
+		server.RenderResponse(w, r, server.NewErrorResponse(http.StatusBadRequest, err))
+		return
+	}
+
+	var accountRequest accountRequest
+	if err := json.NewDecoder(r.Body).Decode(&accountRequest); err != nil {
+		p.logger.Logger(r).Errorf("failed to decode body: %v", err)
+		server.RenderResponse(w, r,
+			server.NewErrorResponse(http.StatusBadRequest, fmt.Errorf("failed to decode request payload")),
+		)
+		return
+	}
+
+	if len(accountRequest.Currencies) == 0 {
+		p.logger.Logger(r).Error("no currencies requested")
+		server.RenderResponse(w, r,
+			server.NewErrorResponse(http.StatusBadRequest, payments.ErrCurrencyRequired),
+		)
+		return
+	}
+
+	account, err := p.service.CreateAccount(r.Context(), accountRequest.Currencies)
+	if err != nil {
+		p.logger.Logger(r).Error(err)
+		renderServiceError(w, r, err)
+		return
+	}
+
+	server.RenderResponse(w, r, &accountResponse{
+		Response: server.NewResponse(http.StatusCreated),
+		Account:  account,
+	})
+}
+
+// @Summary Account
+// @Description Fetch account by id
+// @ID account-get
+// @Tags Account
+// @Produce json
+// @Param accountID path int true "Account ID"
+// @Param Authorization header string true "Bearer token"
+// @Success 200 {object} provider.accountResponse "Account"
+// @Failure 400 {object} server.ErrorResponse "Invalid Request"
+// @Failure 401 {object} server.ErrorResponse "Unauthorized"
+// @Failure 403 {object} server.ErrorResponse "Forbidden"
+// @Failure 404 {object} server.ErrorResponse "Account Not Found"
+// @Failure 500 {object} server.ErrorResponse "Service Error"
+// @Router /v1/accounts/{accountID} [get]
+func (p *AccountsProvider) get(w http.ResponseWriter, r *http.Request) {
+	accountID, ok := p.accountID(w, r)
+	if !ok {
+		return
+	}
+
+	account, err := p.service.Account(r.Context(), accountID)
+	if err != nil {
+		p.logger.Logger(r).Error(err)
+		renderServiceError(w, r, err)
+		return
+	}
+
+	server.RenderResponse(w, r, &accountResponse{
+		Response: server.NewResponse(http.StatusOK),
+		Account:  account,
+	})
+}
+
+// @Summary Update account
+// @Description Change status of the account to active, blocked or closed. Only active account proceeds payments,
+// @Description accepted payments of blocked and closed accounts still can be cancelled. Closed status is final.
+// @ID account-update
+// @Tags Account
+// @Accept json
+// @Produce json
+// @Param accountID path int true "Account ID"
+// @Param account body provider.accountUpdateRequest true "Account status"
+// @Param Authorization header string true "Bearer token of admin"
+// @Success 200 {object} provider.accountResponse "Updated account"
+// @Failure 400 {object} server.ErrorResponse "Invalid Request"
+// @Failure 401 {object} server.ErrorResponse "Unauthorized"
+// @Failure 403 {object} server.ErrorResponse "Forbidden"
+// @Failure 404 {object} server.ErrorResponse "Account Not Found"
+// @Failure 409 {object} server.ErrorResponse "Account Is Closed"
+// @Failure 500 {object} server.ErrorResponse "Service Error"
+// @Router /v1/accounts/{accountID} [patch]
+func (p *AccountsProvider) update(w http.ResponseWriter, r *http.Request) {
+	accountID, ok := p.accountID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := checkContentType(r); err != nil {
+		p.logger.Logger(r).Error(err)
+		server.RenderResponse(w, r, server.NewErrorResponse(http.StatusBadRequest, err))
+		return
+	}
+
+	var request accountUpdateRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		p.logger.Logger(r).Errorf("failed to decode body: %v", err)
+		server.RenderResponse(w, r,
+			server.NewErrorResponse(http.StatusBadRequest, fmt.Errorf("failed to decode request payload")),
+		)
+		return
+	}
+
+	account, err := p.service.SetAccountStatus(r.Context(), accountID, request.Status)
+	if err != nil {
+		p.logger.Logger(r).Error(err)
+		renderServiceError(w, r, err)
+		return
+	}
+
+	server.RenderResponse(w, r, &accountResponse{
+		Response: server.NewResponse(http.StatusOK),
+		Account:  account,
+	})
+}
+
+// @Summary Accounts
+// @Description List accounts ordered by id
+// @ID account-list
+// @Tags Account
+// @Produce json
+// @Param limit query int false "Page size, 20 by default, 100 at most"
+// @Param offset query int false "Number of accounts to skip"
+// @Param Authorization header string true "Bearer token of admin"
+// @Success 200 {object} provider.accountsResponse "Accounts"
+// @Failure 400 {object} server.ErrorResponse "Invalid Request"
+// @Failure 401 {object} server.ErrorResponse "Unauthorized"
+// @Failure 403 {object} server.ErrorResponse "Forbidden"
+// @Failure 500 {object} server.ErrorResponse "Service Error"
+// @Router /v1/accounts [get]
+func (p *AccountsProvider) list(w http.ResponseWriter, r *http.Request) {
+	var page payments.Page
+	for param, value := range map[string]*int{"limit": &page.Limit, "offset": &page.Offset} {
+		raw := r.URL.Query().Get(param)
+		if raw == "" {
+			continue
+		}
+
+		v, err := strconv.Atoi(raw)
+		if err != nil || v < 0 {
+			p.logger.Logger(r).Errorf("incorrect %s value: %v", param, err)
+			server.RenderResponse(w, r,
+				server.NewErrorResponse(http.StatusBadRequest, fmt.Errorf("incorrect %s value", param)),
+			)
+			return
+		}
+		*value = v
+	}
+
+	accounts, total, err := p.service.Accounts(r.Context(), page)
+	if err != nil {
+		p.logger.Logger(r).Error(err)
+		server.RenderResponse(w, r, server.NewErrorResponse(http.StatusInternalServerError, err))
+		return
+	}
+
+	server.RenderResponse(w, r, &accountsResponse{
+		Response: server.NewResponse(http.StatusOK),
+		Accounts: accounts,
+		Total:    total,
+	})
+}
+
+// @Summary Account limits
+// @Description Responsible gaming limits of the account including pending raises
+// @ID account-limits
+// @Tags Account
+// @Produce json
+// @Param accountID path int true "Account ID"
+// @Param Authorization header string true "Bearer token"
+// @Success 200 {object} provider.limitsResponse "Limits"
+// @Failure 400 {object} server.ErrorResponse "Invalid Request"
+// @Failure 401 {object} server.ErrorResponse "Unauthorized"
+// @Failure 403 {object} server.ErrorResponse "Forbidden"
+// @Failure 404 {object} server.ErrorResponse "Account Not Found"
+// @Failure 500 {object} server.ErrorResponse "Service Error"
+// @Router /v1/accounts/{accountID}/limits [get]
+func (p *AccountsProvider) limits(w http.ResponseWriter, r *http.Request) {
+	accountID, ok := p.accountID(w, r)
+	if !ok {
+		return
+	}
+
+	limits, err := p.service.AccountLimits(r.Context(), accountID)
+	if err != nil {
+		p.logger.Logger(r).Error(err)
+		renderServiceError(w, r, err)
+		return
+	}
+
+	server.RenderResponse(w, r, &limitsResponse{
+		Response: server.NewResponse(http.StatusOK),
+		Limits:   limits,
+	})
+}
+
+// @Summary Set account limit
+// @Description Set daily_loss, weekly_loss, monthly_loss or max_stake limit of the account in a currency,
+// @Description zero amount removes the limit. Lowering takes effect at once, raise and removal
+// @Description take effect after LIMIT_COOLING_OFF.
+// @ID account-limit-set
+// @Tags Account
+// @Accept json
+// @Produce json
+// @Param accountID path int true "Account ID"
+// @Param limit body provider.limitRequest true "Limit"
+// @Param Authorization header string true "Bearer token"
+// @Success 200 {object} provider.limitResponse "Limit"
+// @Failure 400 {object} server.ErrorResponse "Invalid Request"
+// @Failure 401 {object} server.ErrorResponse "Unauthorized"
+// @Failure 403 {object} server.ErrorResponse "Forbidden"
+// @Failure 404 {object} server.ErrorResponse "Account Not Found"
+// @Failure 500 {object} server.ErrorResponse "Service Error"
+// @Router /v1/accounts/{accountID}/limits [put]
+func (p *AccountsProvider) setLimit(w http.ResponseWriter, r *http.Request) {
+	accountID, ok := p.accountID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := checkContentType(r); err != nil {
+		p.logger.Logger(r).Error(err)
+		server.RenderResponse(w, r, server.NewErrorResponse(http.StatusBadRequest, err))
+		return
+	}
+
+	var request limitRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		p.logger.Logger(r).Errorf("failed to decode body: %v", err)
+		server.RenderResponse(w, r,
+			server.NewErrorResponse(http.StatusBadRequest, fmt.Errorf("failed to decode request payload")),
+		)
+		return
+	}
+
+	currency, err := p.service.Currency(r.Context(), request.Currency)
+	if err != nil {
+		p.logger.Logger(r).Error(err)
+		renderServiceError(w, r, err)
+		return
+	}
+
+	amount, err := payments.ParseMoney(request.Amount, currency.Places)
+	if err != nil || amount.IsNegative() {
+		p.logger.Logger(r).Errorf("incorrect amount value: %v", err)
+		server.RenderResponse(w, r,
+			server.NewErrorResponse(http.StatusBadRequest, fmt.Errorf("incorrect amount value")),
+		)
+		return
+	}
+
+	limit, err := p.service.SetAccountLimit(r.Context(), accountID, request.Kind, currency.Code, amount)
+	if err != nil {
+		p.logger.Logger(r).Error(err)
+		renderServiceError(w, r, err)
+		return
+	}
+
+	server.RenderResponse(w, r, &limitResponse{
+		Response: server.NewResponse(http.StatusOK),
+		Limit:    limit,
+	})
+}
+
+// @Summary Account self-exclusion
+// @Description Self-exclusion state of the account
+// @ID account-exclusion
+// @Tags Account
+// @Produce json
+// @Param accountID path int true "Account ID"
+// @Param Authorization header string true "Bearer token"
+// @Success 200 {object} provider.exclusionResponse "Self-exclusion"
+// @Failure 400 {object} server.ErrorResponse "Invalid Request"
+// @Failure 401 {object} server.ErrorResponse "Unauthorized"
+// @Failure 403 {object} server.ErrorResponse "Forbidden"
+// @Failure 404 {object} server.ErrorResponse "Account Not Found"
+// @Failure 500 {object} server.ErrorResponse "Service Error"
+// @Router /v1/accounts/{accountID}/exclusion [get]
+func (p *AccountsProvider) exclusion(w http.ResponseWriter, r *http.Request) {
+	accountID, ok := p.accountID(w, r)
+	if !ok {
+		return
+	}
+
+	exclusion, err := p.service.Exclusion(r.Context(), accountID)
+	if err != nil {
+		p.logger.Logger(r).Error(err)
+		renderServiceError(w, r, err)
+		return
+	}
+
+	server.RenderResponse(w, r, &exclusionResponse{
+		Response:  server.NewResponse(http.StatusOK),
+		Exclusion: exclusion,
+	})
+}
+
+// @Summary Self-exclude account
+// @Description Exclude the account from payments for a number of days or permanently.
+// @Description Active self-exclusion can only be extended. Cancellations of payments are still allowed.
+// @ID account-exclude
+// @Tags Account
+// @Accept json
+// @Produce json
+// @Param accountID path int true "Account ID"
+// @Param exclusion body provider.exclusionRequest true "Days or permanent"
+// @Param Authorization header string true "Bearer token"
+// @Success 200 {object} provider.exclusionResponse "Self-exclusion"
+// @Failure 400 {object} server.ErrorResponse "Invalid Request"
+// @Failure 401 {object} server.ErrorResponse "Unauthorized"
+// @Failure 403 {object} server.ErrorResponse "Forbidden"
+// @Failure 404 {object} server.ErrorResponse "Account Not Found"
+// @Failure 409 {object} server.ErrorResponse "Self-Exclusion Can't Be Shortened"
+// @Failure 500 {object} server.ErrorResponse "Service Error"
+// @Router /v1/accounts/{accountID}/exclusion [put]
+func (p *AccountsProvider) exclude(w http.ResponseWriter, r *http.Request) {
+	accountID, ok := p.accountID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := checkContentType(r); err != nil {
+		p.logger.Logger(r).Error(err)
+		server.RenderResponse(w, r, server.NewErrorResponse(http.StatusBadRequest, err))
+		return
+	}
+
+	var request exclusionRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		p.logger.Logger(r).Errorf("failed to decode body: %v", err)
+		server.RenderResponse(w, r,
+			server.NewErrorResponse(http.StatusBadRequest, fmt.Errorf("failed to decode request payload")),
+		)
+		return
+	}
+
+	if request.Permanent == (request.Days > 0) || request.Days < 0 {
+		p.logger.Logger(r).Errorf("incorrect exclusion: %+v", request)
+		server.RenderResponse(w, r,
+			server.NewErrorResponse(http.StatusBadRequest, fmt.Errorf("either positive days or permanent must be set")),
+		)
+		return
+	}
+
+	exclusion, err := p.service.ExcludeAccount(r.Context(), accountID, time.Duration(request.Days)*24*time.Hour)
+	if err != nil {
+		p.logger.Logger(r).Error(err)
+		renderServiceError(w, r, err)
+		return
+	}
+
+	server.RenderResponse(w, r, &exclusionResponse{
+		Response:  server.NewResponse(http.StatusOK),
+		Exclusion: exclusion,
+	})
+}
+
+// @Summary Account self-exclusion history
+// @Description Audit history of self-exclusion changes of the account, the latest first
+// @ID account-exclusion-history
+// @Tags Account
+// @Produce json
+// @Param accountID path int true "Account ID"
+// @Param Authorization header string true "Bearer token"
+// @Success 200 {object} provider.exclusionHistoryResponse "History"
+// @Failure 400 {object} server.ErrorResponse "Invalid Request"
+// @Failure 401 {object} server.ErrorResponse "Unauthorized"
+// @Failure 403 {object} server.ErrorResponse "Forbidden"
+// @Failure 404 {object} server.ErrorResponse "Account Not Found"
+// @Failure 500 {object} server.ErrorResponse "Service Error"
+// @Router /v1/accounts/{accountID}/exclusion/history [get]
+func (p *AccountsProvider) exclusionHistory(w http.ResponseWriter, r *http.Request) {
+	accountID, ok := p.accountID(w, r)
+	if !ok {
+		return
+	}
+
+	history, err := p.service.ExclusionHistory(r.Context(), accountID)
+	if err != nil {
+		p.logger.Logger(r).Error(err)
+		renderServiceError(w, r, err)
+		return
+	}
+
+	server.RenderResponse(w, r, &exclusionHistoryResponse{
+		Response: server.NewResponse(http.StatusOK),
+		History:  history,
+	})
+}
+
+// accountID returns id of account from URL the principal can access.
+// Otherwise error is rendered and false is returned.
+func (p *AccountsProvider) accountID(w http.ResponseWriter, r *http.Request) (int, bool) {
+	accountID, err := strconv.Atoi(chi.URLParam(r, "accountID"))
+	if err != nil || accountID <= 0 {
+		p.logger.Logger(r).Errorf("incorrect account id: %v", err)
+		server.RenderResponse(w, r,
+			server.NewErrorResponse(http.StatusBadRequest, fmt.Errorf("incorrect account id")),
+		)
+		return 0, false
+	}
+
+	principal, _ := auth.PrincipalFromContext(r.Context())
+	if !principal.CanAccess(accountID) {
+		server.RenderResponse(w, r,
+			server.NewErrorResponse(http.StatusForbidden, fmt.Errorf("access to account denied")),
+		)
+		return 0, false
+	}
+
+	return accountID, true
+}
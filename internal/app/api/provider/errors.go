@@ -0,0 +1,67 @@
+package provider
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/dink10/enlabs/internal/pkg/payments"
+	"github.com/dink10/enlabs/internal/pkg/server"
+)
+
+// errorStatuses maps payments errors to HTTP status codes.
+var errorStatuses = map[*payments.Error]int{
+	payments.ErrAccountNotFound:      http.StatusNotFound,
+	payments.ErrAccountInactive:      http.StatusForbidden,
+	payments.ErrAccountClosed:        http.StatusConflict,
+	payments.ErrInvalidAccountStatus: http.StatusBadRequest,
+	payments.ErrAccountExcluded:      http.StatusForbidden,
+	payments.ErrExclusionActive:      http.StatusConflict,
+	payments.ErrInvalidExclusion:     http.StatusBadRequest,
+	payments.ErrPaymentNotFound:      http.StatusNotFound,
+	payments.ErrPaymentCancelled:     http.StatusConflict,
+	payments.ErrPaymentNotProcessed:  http.StatusConflict,
+	payments.ErrDuplicateTransaction: http.StatusConflict,
+	payments.ErrInvalidTransition:    http.StatusConflict,
+	payments.ErrPaymentLocked:        http.StatusConflict,
+	payments.ErrInsufficientFunds:    http.StatusPaymentRequired,
+	payments.ErrUnknownSourceType:    http.StatusBadRequest,
+	payments.ErrSourceTypeDisabled:   http.StatusBadRequest,
+	payments.ErrSourceTypeNotFound:   http.StatusNotFound,
+	payments.ErrDuplicateSourceType:  http.StatusConflict,
+	payments.ErrInvalidSourceType:    http.StatusBadRequest,
+	payments.ErrUnsupportedCurrency:  http.StatusBadRequest,
+	payments.ErrCurrencyRequired:     http.StatusBadRequest,
+	payments.ErrInvalidLimitKind:     http.StatusBadRequest,
+	payments.ErrCurrencyMismatch:     http.StatusBadRequest,
+}
+
+// errorStatus returns HTTP status code for error returned by payments.Service.
+// Errors which aren't payments errors are failures of the service itself.
+func errorStatus(err error) int {
+	var violation *payments.RuleViolationError
+	if errors.As(err, &violation) {
+		return http.StatusUnprocessableEntity
+	}
+
+	var exceeded *payments.LimitExceededError
+	if errors.As(err, &exceeded) {
+		return http.StatusUnprocessableEntity
+	}
+
+	var paymentsErr *payments.Error
+	if !errors.As(err, &paymentsErr) {
+		return http.StatusInternalServerError
+	}
+
+	status, ok := errorStatuses[paymentsErr]
+	if !ok {
+		return http.StatusBadRequest
+	}
+
+	return status
+}
+
+// renderServiceError renders error returned by payments.Service.
+func renderServiceError(w http.ResponseWriter, r *http.Request, err error) {
+	server.RenderResponse(w, r, server.NewErrorResponse(errorStatus(err), err))
+}
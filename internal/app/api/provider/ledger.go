@@ -0,0 +1,71 @@
+package provider
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi"
+
+	"github.com/dink10/enlabs/internal/pkg/auth"
+	"github.com/dink10/enlabs/internal/pkg/logger"
+	"github.com/dink10/enlabs/internal/pkg/payments"
+	"github.com/dink10/enlabs/internal/pkg/server"
+)
+
+// LedgerProvider provides endpoints to audit the ledger.
+type LedgerProvider struct {
+	service       *payments.Service
+	authenticator *auth.Authenticator
+	logger        *logger.ProviderLogger
+}
+
+// NewLedgerProvider returns a new instance of LedgerProvider.
+func NewLedgerProvider(service *payments.Service, authenticator *auth.Authenticator) LedgerProvider {
+	return LedgerProvider{
+		service:       service,
+		authenticator: authenticator,
+		logger:        logger.NewProviderLogger("ledger"),
+	}
+}
+
+// Router returns LedgerProvider router.
+func (p *LedgerProvider) Router() http.Handler {
+	r := chi.NewRouter()
+
+	r.Route("/", func(r chi.Router) {
+		r.Use(p.authenticator.Middleware)
+		r.Use(auth.RequireAdmin)
+		r.Get("/mismatches", p.mismatches)
+	})
+
+	return r
+}
+
+type mismatchesResponse struct {
+	*server.Response
+	Mismatches []payments.BalanceMismatch `json:"mismatches"`
+}
+
+// @Summary Ledger reconciliation
+// @Description Balances which differ from the sum of their wallet ledger entries, empty if books are consistent
+// @ID ledger-mismatches
+// @Tags Ledger
+// @Produce json
+// @Param Authorization header string true "Bearer token of admin"
+// @Success 200 {object} provider.mismatchesResponse "Mismatched balances"
+// @Failure 401 {object} server.ErrorResponse "Unauthorized"
+// @Failure 403 {object} server.ErrorResponse "Forbidden"
+// @Failure 500 {object} server.ErrorResponse "Service Error"
+// @Router /v1/ledger/mismatches [get]
+func (p *LedgerProvider) mismatches(w http.ResponseWriter, r *http.Request) {
+	mismatches, err := p.service.LedgerMismatches(r.Context())
+	if err != nil {
+		p.logger.Logger(r).Error(err)
+		server.RenderResponse(w, r, server.NewErrorResponse(http.StatusInternalServerError, err))
+		return
+	}
+
+	server.RenderResponse(w, r, &mismatchesResponse{
+		Response:   server.NewResponse(http.StatusOK),
+		Mismatches: mismatches,
+	})
+}
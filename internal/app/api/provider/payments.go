@@ -1,31 +1,40 @@
 package provider
 
 import (
-	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/go-chi/chi"
 	"github.com/gookit/validate"
 
+	"github.com/dink10/enlabs/internal/pkg/auth"
 	"github.com/dink10/enlabs/internal/pkg/logger"
 	"github.com/dink10/enlabs/internal/pkg/payments"
 	"github.com/dink10/enlabs/internal/pkg/server"
+	"github.com/dink10/enlabs/internal/pkg/signature"
 )
 
 // PaymentsProvider provides endpoints to interact with PAYMENT.Service.
 type PaymentsProvider struct {
-	service *payments.Service
-	logger  *logger.ProviderLogger
+	service       *payments.Service
+	signature     *signature.Service
+	authenticator *auth.Authenticator
+	logger        *logger.ProviderLogger
 }
 
 // NewPaymentProvider returns a new instance of PaymentsProvider.
-func NewPaymentProvider(service *payments.Service) PaymentsProvider {
+func NewPaymentProvider(
+	service *payments.Service, signature *signature.Service, authenticator *auth.Authenticator,
+) PaymentsProvider {
 	return PaymentsProvider{
-		service: service,
-		logger:  logger.NewProviderLogger("payments"),
+		service:       service,
+		signature:     signature,
+		authenticator: authenticator,
+		logger:        logger.NewProviderLogger("payments"),
 	}
 }
 
@@ -34,9 +43,12 @@ func (p *PaymentsProvider) Router() http.Handler {
 	r := chi.NewRouter()
 
 	r.Route("/", func(r chi.Router) {
-		r.Use(p.userMiddleware)
-		r.Post("/", p.create)
+		r.Use(p.authenticator.Middleware)
+		r.With(p.signature.Middleware).Post("/", p.create)
+		r.Get("/", p.list)
 		r.Get("/balance", p.balance)
+		r.Get("/{transactionID}", p.get)
+		r.With(auth.RequireAdmin).Post("/{transactionID}/cancel", p.cancel)
 	})
 
 	return r
@@ -47,6 +59,7 @@ type paymentRequest struct {
 	State         string `json:"state" validate:"stateValidator"`
 	TransactionId string `json:"transactionId" validate:"required"`
 	Amount        string `json:"amount" validate:"required"`
+	Currency      string `json:"currency" validate:"required"`
 }
 
 // StateValidator source validator in the source struct.
@@ -61,15 +74,26 @@ func (pr paymentRequest) StateValidator(val string) bool {
 
 type paymentsResponse struct {
 	*server.Response
-	Message string `json:"message"`
+	Message string            `json:"message"`
+	Replay  bool              `json:"replay,omitempty"`
+	Payment payments.Payment  `json:"payment"`
+	Balance *payments.Balance `json:"balance,omitempty"`
 }
 
-func (p *PaymentsProvider) userMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// it could be middleware with user recognition, now - just hardcoded
-		ctx := context.WithValue(r.Context(), "account_id", 1)
-		next.ServeHTTP(w, r.WithContext(ctx))
-	})
+type paymentResponse struct {
+	*server.Response
+	Payment payments.Payment `json:"payment"`
+}
+
+type paymentListResponse struct {
+	*server.Response
+	Payments   []payments.Payment `json:"payments"`
+	NextCursor string             `json:"nextCursor,omitempty"`
+}
+
+type balancesResponse struct {
+	*server.Response
+	Balances []payments.Balance `json:"balances"`
 }
 
 // @Summary Payment processing
@@ -81,26 +105,25 @@ func (p *PaymentsProvider) userMiddleware(next http.Handler) http.Handler {
 // @Param payment body provider.paymentRequest true "Payment data to create"
 // @Header 200 {string} Source-Type "payment"
 // @Param Source-Type header string true "With the bearer started"
+// @Param Signature header string true "Hex HMAC-SHA256 of timestamp, nonce and body keyed by secret of source type"
+// @Param Signature-Timestamp header int true "Unix time of the request"
+// @Param Signature-Nonce header string true "Unique value, never reused by source type"
+// @Param Authorization header string true "Bearer token"
 // @Content-Type application/json
-// @Success 200 {object} provider.paymentsResponse "Proceeded payment"
+// @Success 200 {object} provider.paymentsResponse "Recorded payment and balance, replay is set on resubmission"
 // @Failure 400 {object} server.ErrorResponse "Invalid Request"
+// @Failure 401 {object} server.ErrorResponse "Unauthorized Or Invalid Signature"
+// @Failure 402 {object} server.ErrorResponse "Insufficient Funds"
+// @Failure 403 {object} server.ErrorResponse "Principal Without Account, Account Is Inactive Or Self-Excluded"
 // @Failure 404 {object} server.ErrorResponse "Account Not Found"
+// @Failure 409 {object} server.ErrorResponse "Transaction ID Resubmitted With Different Payload"
+// @Failure 422 {object} server.ErrorResponse "Rule Of Source Type Violated Or Account Limit Exceeded"
 // @Failure 500 {object} server.ErrorResponse "Service Error"
 // @Router /v1/payments [post]
 func (p *PaymentsProvider) create(w http.ResponseWriter, r *http.Request) {
-	if r.Header.Get(server.HeaderContentType) != server.JsonContentType {
-		p.logger.Logger(r).Errorf(
-			"incorrect Content-Type, required %s, got %s",
-			server.JsonContentType,
-			r.Header.Get(server.HeaderContentType),
-		)
-		server.RenderResponse(
-			w, r, server.NewErrorResponse(http.StatusBadRequest, fmt.Errorf(
-				"incorrect Content-Type, required %s, got %s",
-				server.JsonContentType,
-				r.Header.Get(server.HeaderContentType)),
-			),
-		)
+	if err := checkContentType(r); err != nil {
+		p.logger.Logger(r).Error(err)
+		server.RenderResponse(w, r, server.NewErrorResponse(http.StatusBadRequest, err))
 		return
 	}
 
@@ -121,71 +144,290 @@ func (p *PaymentsProvider) create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	amount, err := strconv.ParseFloat(paymentRequest.Amount, 64)
-	if err != nil || amount < 0.0 {
+	currency, err := p.service.Currency(r.Context(), paymentRequest.Currency)
+	if err != nil {
+		p.logger.Logger(r).Error(err)
+		renderServiceError(w, r, err)
+		return
+	}
+
+	amount, err := payments.ParseMoney(paymentRequest.Amount, currency.Places)
+	if err != nil || amount <= 0 {
 		p.logger.Logger(r).Errorf("incorrect amount value: %v", err)
-		server.RenderResponse(w, r,
-			server.NewErrorResponse(http.StatusBadRequest, fmt.Errorf("incorrect amount value")),
-		)
+		amountErr := fmt.Errorf("incorrect amount value")
+		if errors.Is(err, payments.ErrTooManyPlaces) {
+			amountErr = fmt.Errorf("incorrect amount value: %v", err)
+		}
+		server.RenderResponse(w, r, server.NewErrorResponse(http.StatusBadRequest, amountErr))
 		return
 	}
 
 	sourceType, err := p.service.SourceTypeID(r.Context(), r.Header.Get(server.HeaderSourceType))
 	if err != nil {
 		p.logger.Logger(r).Errorf("%v", err)
-		server.RenderResponse(w, r, server.NewErrorResponse(http.StatusBadRequest, err))
+		renderServiceError(w, r, err)
 		return
 	}
 
-	accountID, ok := r.Context().Value("account_id").(int)
+	principal, ok := p.accountPrincipal(w, r)
 	if !ok {
-		p.logger.Logger(r).Error("wrong account_id")
-		server.RenderResponse(w, r, server.NewErrorResponse(http.StatusBadRequest, fmt.Errorf("wrong account_id")))
 		return
 	}
 
 	payment := payments.Payment{
-		AccountID:     accountID,
+		AccountID:     principal.AccountID,
 		TransactionID: paymentRequest.TransactionId,
 		State:         paymentRequest.State,
 		Amount:        amount,
+		Currency:      currency.Code,
 		SourceType:    sourceType,
-		Processed:     true,
 	}
 
-	if err = p.service.ProceedPayment(r.Context(), payment); err != nil {
+	proceeded, replay, err := p.service.ProceedPayment(r.Context(), payment)
+	if err != nil {
 		p.logger.Logger(r).Error(err)
-		server.RenderResponse(w, r, server.NewErrorResponse(http.StatusBadRequest, err))
+		renderServiceError(w, r, err)
 		return
 	}
 
-	server.RenderResponse(w, r, &paymentsResponse{
+	response := paymentsResponse{
 		Response: server.NewResponse(http.StatusOK),
 		Message:  "payment was successfully proceed",
-	})
+		Replay:   replay,
+		Payment:  proceeded,
+	}
+
+	// payment is already recorded, so response isn't failed by balance lookup
+	balances, err := p.service.Balances(r.Context())
+	if err != nil {
+		p.logger.Logger(r).Error(err)
+	}
+	for i := range balances {
+		if balances[i].Currency == proceeded.Currency {
+			response.Balance = &balances[i]
+		}
+	}
+
+	server.RenderResponse(w, r, &response)
 }
 
 // @Summary Account Balance
-// @Description Balance
+// @Description Balances of the account in every currency
 // @ID show-balance
 // @Tags Balance
 // @Produce json
+// @Param Authorization header string true "Bearer token"
 // @Content-Type application/json
-// @Success 200 {object} provider.paymentsResponse "Balance"
+// @Success 200 {object} provider.balancesResponse "Balances"
 // @Failure 400 {object} server.ErrorResponse "Invalid Request"
+// @Failure 401 {object} server.ErrorResponse "Unauthorized"
 // @Failure 404 {object} server.ErrorResponse "Not Found"
 // @Failure 500 {object} server.ErrorResponse "Service Error"
 // @Router /v1/payments/balance [get]
 func (p *PaymentsProvider) balance(w http.ResponseWriter, r *http.Request) {
-	balance, err := p.service.Balance(r.Context())
+	balances, err := p.service.Balances(r.Context())
 	if err != nil {
 		p.logger.Logger(r).Error(err)
 		server.RenderResponse(w, r, server.NewErrorResponse(http.StatusInternalServerError, err))
 		return
 	}
 
-	server.RenderResponse(w, r, &paymentsResponse{
+	server.RenderResponse(w, r, &balancesResponse{
+		Response: server.NewResponse(http.StatusOK),
+		Balances: balances,
+	})
+}
+
+// @Summary Payment history
+// @Description Payments of the account ordered by id descending
+// @ID payment-list
+// @Tags Account
+// @Produce json
+// @Param state query string false "Payment state: win or lost"
+// @Param sourceType query string false "Source type value, disabled source types included"
+// @Param status query string false "Payment status: accepted, rejected, cancelled or cancel_failed"
+// @Param createdFrom query string false "Lower bound of created_at, RFC3339, inclusive"
+// @Param createdTo query string false "Upper bound of created_at, RFC3339, exclusive"
+// @Param cursor query string false "nextCursor of the previous page"
+// @Param limit query int false "Page size, 20 by default, 100 at most"
+// @Param Authorization header string true "Bearer token"
+// @Success 200 {object} provider.paymentListResponse "Payments"
+// @Failure 400 {object} server.ErrorResponse "Invalid Request"
+// @Failure 401 {object} server.ErrorResponse "Unauthorized"
+// @Failure 403 {object} server.ErrorResponse "Principal Without Account"
+// @Failure 500 {object} server.ErrorResponse "Service Error"
+// @Router /v1/payments [get]
+func (p *PaymentsProvider) list(w http.ResponseWriter, r *http.Request) {
+	principal, ok := p.accountPrincipal(w, r)
+	if !ok {
+		return
+	}
+
+	filter, err := p.paymentFilter(r)
+	if err != nil {
+		p.logger.Logger(r).Error(err)
+		server.RenderResponse(w, r, server.NewErrorResponse(http.StatusBadRequest, err))
+		return
+	}
+	filter.AccountID = principal.AccountID
+
+	pays, next, err := p.service.Payments(r.Context(), filter)
+	if err != nil {
+		p.logger.Logger(r).Error(err)
+		server.RenderResponse(w, r, server.NewErrorResponse(http.StatusInternalServerError, err))
+		return
+	}
+
+	response := paymentListResponse{
+		Response: server.NewResponse(http.StatusOK),
+		Payments: pays,
+	}
+	if next != 0 {
+		response.NextCursor = strconv.Itoa(next)
+	}
+
+	server.RenderResponse(w, r, &response)
+}
+
+// @Summary Payment
+// @Description Fetch payment by transaction id to find out whether it was applied or later cancelled
+// @ID payment-get
+// @Tags Account
+// @Produce json
+// @Param transactionID path string true "Transaction ID"
+// @Param Authorization header string true "Bearer token"
+// @Success 200 {object} provider.paymentResponse "Payment"
+// @Failure 401 {object} server.ErrorResponse "Unauthorized"
+// @Failure 404 {object} server.ErrorResponse "Payment Not Found"
+// @Failure 500 {object} server.ErrorResponse "Service Error"
+// @Router /v1/payments/{transactionID} [get]
+func (p *PaymentsProvider) get(w http.ResponseWriter, r *http.Request) {
+	payment, err := p.service.Payment(r.Context(), chi.URLParam(r, "transactionID"))
+	if err != nil {
+		p.logger.Logger(r).Error(err)
+		renderServiceError(w, r, err)
+		return
+	}
+
+	// foreign payments are reported as absent not to disclose transaction ids of other accounts
+	principal, _ := auth.PrincipalFromContext(r.Context())
+	if !principal.CanAccess(payment.AccountID) {
+		server.RenderResponse(w, r, server.NewErrorResponse(http.StatusNotFound, payments.ErrPaymentNotFound))
+		return
+	}
+
+	server.RenderResponse(w, r, &paymentResponse{
 		Response: server.NewResponse(http.StatusOK),
-		Message:  fmt.Sprintf("%f", balance.Balance),
+		Payment:  payment,
 	})
 }
+
+// @Summary Payment cancellation
+// @Description Reverse balance change made by payment, the same way processing does.
+// @Description Payment is reversed at most once, repeated cancellation is rejected.
+// @ID payment-cancel
+// @Tags Account
+// @Produce json
+// @Param transactionID path string true "Transaction ID"
+// @Param Authorization header string true "Bearer token of admin"
+// @Success 200 {object} provider.paymentResponse "Cancelled payment"
+// @Failure 400 {object} server.ErrorResponse "Invalid Request"
+// @Failure 401 {object} server.ErrorResponse "Unauthorized"
+// @Failure 403 {object} server.ErrorResponse "Forbidden"
+// @Failure 404 {object} server.ErrorResponse "Payment Not Found"
+// @Failure 402 {object} server.ErrorResponse "Insufficient Funds"
+// @Failure 409 {object} server.ErrorResponse "Payment Already Cancelled Or Rejected"
+// @Failure 500 {object} server.ErrorResponse "Service Error"
+// @Router /v1/payments/{transactionID}/cancel [post]
+func (p *PaymentsProvider) cancel(w http.ResponseWriter, r *http.Request) {
+	payment, err := p.service.CancelPayment(r.Context(), chi.URLParam(r, "transactionID"))
+	if err != nil {
+		p.logger.Logger(r).Error(err)
+		renderServiceError(w, r, err)
+		return
+	}
+
+	server.RenderResponse(w, r, &paymentResponse{
+		Response: server.NewResponse(http.StatusOK),
+		Payment:  payment,
+	})
+}
+
+func (p *PaymentsProvider) paymentFilter(r *http.Request) (payments.PaymentFilter, error) {
+	var filter payments.PaymentFilter
+	query := r.URL.Query()
+
+	if state := query.Get("state"); state != "" {
+		if !(paymentRequest{}).StateValidator(state) {
+			return filter, fmt.Errorf("incorrect state value")
+		}
+		filter.State = state
+	}
+
+	if sourceType := query.Get("sourceType"); sourceType != "" {
+		v, err := p.service.SourceType(r.Context(), sourceType)
+		if err != nil {
+			return filter, fmt.Errorf("incorrect sourceType value")
+		}
+		filter.SourceType = v.ID
+	}
+
+	if status := payments.PaymentStatus(query.Get("status")); status != "" {
+		if !status.Valid() {
+			return filter, fmt.Errorf("incorrect status value")
+		}
+		filter.Status = status
+	}
+
+	for param, value := range map[string]*time.Time{"createdFrom": &filter.CreatedFrom, "createdTo": &filter.CreatedTo} {
+		raw := query.Get(param)
+		if raw == "" {
+			continue
+		}
+
+		v, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return filter, fmt.Errorf("incorrect %s value", param)
+		}
+		*value = v
+	}
+
+	for param, value := range map[string]*int{"cursor": &filter.After, "limit": &filter.Limit} {
+		raw := query.Get(param)
+		if raw == "" {
+			continue
+		}
+
+		v, err := strconv.Atoi(raw)
+		if err != nil || v <= 0 {
+			return filter, fmt.Errorf("incorrect %s value", param)
+		}
+		*value = v
+	}
+
+	return filter, nil
+}
+
+// accountPrincipal returns authenticated principal owning an account. Otherwise
+// 401 is rendered if request isn't authenticated, 403 if principal, e.g. admin,
+// has no account to proceed payments of.
+func (p *PaymentsProvider) accountPrincipal(w http.ResponseWriter, r *http.Request) (auth.Principal, bool) {
+	principal, ok := auth.PrincipalFromContext(r.Context())
+	if !ok {
+		p.logger.Logger(r).Error("no authenticated principal")
+		server.RenderResponse(w, r,
+			server.NewErrorResponse(http.StatusUnauthorized, fmt.Errorf("no authenticated principal")),
+		)
+		return auth.Principal{}, false
+	}
+
+	if principal.AccountID == 0 {
+		p.logger.Logger(r).Error("principal without account")
+		server.RenderResponse(w, r,
+			server.NewErrorResponse(http.StatusForbidden, fmt.Errorf("principal without account")),
+		)
+		return auth.Principal{}, false
+	}
+
+	return principal, true
+}
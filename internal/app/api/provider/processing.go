@@ -0,0 +1,218 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi"
+
+	cycle "github.com/dink10/enlabs/internal/app/processing"
+	"github.com/dink10/enlabs/internal/pkg/auth"
+	"github.com/dink10/enlabs/internal/pkg/logger"
+	"github.com/dink10/enlabs/internal/pkg/processing"
+	"github.com/dink10/enlabs/internal/pkg/server"
+)
+
+// ProcessingProvider provides endpoints to inspect and trigger post processing.
+type ProcessingProvider struct {
+	service       *processing.Service
+	job           *cycle.Job
+	authenticator *auth.Authenticator
+	runTimeout    time.Duration
+	logger        *logger.ProviderLogger
+}
+
+// NewProcessingProvider returns a new instance of ProcessingProvider.
+// Triggered run isn't bound to its request, it's given runTimeout to finish.
+func NewProcessingProvider(
+	service *processing.Service, job *cycle.Job, authenticator *auth.Authenticator, runTimeout time.Duration,
+) ProcessingProvider {
+	return ProcessingProvider{
+		service:       service,
+		job:           job,
+		authenticator: authenticator,
+		runTimeout:    runTimeout,
+		logger:        logger.NewProviderLogger("processing"),
+	}
+}
+
+// Router returns ProcessingProvider router.
+func (p *ProcessingProvider) Router() http.Handler {
+	r := chi.NewRouter()
+
+	r.Route("/", func(r chi.Router) {
+		r.Use(p.authenticator.Middleware)
+		r.Use(auth.RequireAdmin)
+		r.Get("/runs", p.runs)
+		r.Post("/runs", p.trigger)
+		r.Get("/runs/{runID}", p.run)
+	})
+
+	return r
+}
+
+type runsResponse struct {
+	*server.Response
+	Runs []processing.Run `json:"runs"`
+}
+
+type runResponse struct {
+	*server.Response
+	Run processing.Run `json:"run"`
+}
+
+type previewResponse struct {
+	*server.Response
+	Preview processing.Preview `json:"preview"`
+}
+
+// @Summary Processing runs
+// @Description Recent cancellation cycles of post processing, the latest first
+// @ID processing-runs
+// @Tags Processing
+// @Produce json
+// @Param limit query int false "Number of runs, 20 by default, 100 at most"
+// @Param Authorization header string true "Bearer token of admin"
+// @Success 200 {object} provider.runsResponse "Runs"
+// @Failure 400 {object} server.ErrorResponse "Invalid Request"
+// @Failure 401 {object} server.ErrorResponse "Unauthorized"
+// @Failure 403 {object} server.ErrorResponse "Forbidden"
+// @Failure 500 {object} server.ErrorResponse "Service Error"
+// @Router /v1/processing/runs [get]
+func (p *ProcessingProvider) runs(w http.ResponseWriter, r *http.Request) {
+	var limit int
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		v, err := strconv.Atoi(raw)
+		if err != nil || v <= 0 {
+			p.logger.Logger(r).Errorf("incorrect limit value: %v", err)
+			server.RenderResponse(w, r,
+				server.NewErrorResponse(http.StatusBadRequest, fmt.Errorf("incorrect limit value")),
+			)
+			return
+		}
+		limit = v
+	}
+
+	runs, err := p.service.Runs(r.Context(), limit)
+	if err != nil {
+		p.logger.Logger(r).Error(err)
+		server.RenderResponse(w, r, server.NewErrorResponse(http.StatusInternalServerError, err))
+		return
+	}
+
+	server.RenderResponse(w, r, &runsResponse{
+		Response: server.NewResponse(http.StatusOK),
+		Runs:     runs,
+	})
+}
+
+// @Summary Processing run
+// @Description Cancellation cycle of post processing with outcome of every payment
+// @ID processing-run
+// @Tags Processing
+// @Produce json
+// @Param runID path int true "Run ID"
+// @Param Authorization header string true "Bearer token of admin"
+// @Success 200 {object} provider.runResponse "Run"
+// @Failure 400 {object} server.ErrorResponse "Invalid Request"
+// @Failure 401 {object} server.ErrorResponse "Unauthorized"
+// @Failure 403 {object} server.ErrorResponse "Forbidden"
+// @Failure 404 {object} server.ErrorResponse "Run Not Found"
+// @Failure 500 {object} server.ErrorResponse "Service Error"
+// @Router /v1/processing/runs/{runID} [get]
+func (p *ProcessingProvider) run(w http.ResponseWriter, r *http.Request) {
+	runID, err := strconv.Atoi(chi.URLParam(r, "runID"))
+	if err != nil {
+		p.logger.Logger(r).Errorf("incorrect run id: %v", err)
+		server.RenderResponse(w, r,
+			server.NewErrorResponse(http.StatusBadRequest, fmt.Errorf("incorrect run id")),
+		)
+		return
+	}
+
+	run, err := p.service.Run(r.Context(), runID)
+	switch {
+	case errors.Is(err, processing.ErrRunNotFound):
+		server.RenderResponse(w, r, server.NewErrorResponse(http.StatusNotFound, err))
+		return
+	case err != nil:
+		p.logger.Logger(r).Error(err)
+		server.RenderResponse(w, r, server.NewErrorResponse(http.StatusInternalServerError, err))
+		return
+	}
+
+	server.RenderResponse(w, r, &runResponse{
+		Response: server.NewResponse(http.StatusOK),
+		Run:      run,
+	})
+}
+
+// @Summary Trigger processing run
+// @Description Runs a cancellation cycle right away. With dryRun payments which would be cancelled
+// @Description and balances they would leave are returned, nothing is committed nor recorded.
+// @ID processing-trigger
+// @Tags Processing
+// @Produce json
+// @Param dryRun query bool false "Preview the run without committing"
+// @Param Authorization header string true "Bearer token of admin"
+// @Success 201 {object} provider.runResponse "Recorded run"
+// @Success 200 {object} provider.previewResponse "Preview of dry run"
+// @Failure 400 {object} server.ErrorResponse "Invalid Request"
+// @Failure 401 {object} server.ErrorResponse "Unauthorized"
+// @Failure 403 {object} server.ErrorResponse "Forbidden"
+// @Failure 409 {object} server.ErrorResponse "Run In Progress"
+// @Failure 500 {object} server.ErrorResponse "Service Error"
+// @Router /v1/processing/runs [post]
+func (p *ProcessingProvider) trigger(w http.ResponseWriter, r *http.Request) {
+	var dryRun bool
+	if raw := r.URL.Query().Get("dryRun"); raw != "" {
+		v, err := strconv.ParseBool(raw)
+		if err != nil {
+			p.logger.Logger(r).Errorf("incorrect dryRun value: %v", err)
+			server.RenderResponse(w, r,
+				server.NewErrorResponse(http.StatusBadRequest, fmt.Errorf("incorrect dryRun value")),
+			)
+			return
+		}
+		dryRun = v
+	}
+
+	if dryRun {
+		preview, err := p.job.DryRun(r.Context())
+		if err != nil {
+			p.logger.Logger(r).Error(err)
+			server.RenderResponse(w, r, server.NewErrorResponse(http.StatusInternalServerError, err))
+			return
+		}
+
+		server.RenderResponse(w, r, &previewResponse{
+			Response: server.NewResponse(http.StatusOK),
+			Preview:  preview,
+		})
+		return
+	}
+
+	// run isn't interrupted by disconnected client, it's bounded by shutdown timeout instead
+	ctx, cancel := context.WithTimeout(context.Background(), p.runTimeout)
+	defer cancel()
+
+	run, err := p.job.RunOnce(ctx)
+	switch {
+	case errors.Is(err, processing.ErrRunInProgress):
+		server.RenderResponse(w, r, server.NewErrorResponse(http.StatusConflict, err))
+		return
+	case err != nil:
+		p.logger.Logger(r).Error(err)
+		server.RenderResponse(w, r, server.NewErrorResponse(http.StatusInternalServerError, err))
+		return
+	}
+
+	server.RenderResponse(w, r, &runResponse{
+		Response: server.NewResponse(http.StatusCreated),
+		Run:      run,
+	})
+}
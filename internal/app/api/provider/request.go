@@ -0,0 +1,21 @@
+package provider
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/dink10/enlabs/internal/pkg/server"
+)
+
+// checkContentType returns error if request body is not JSON.
+func checkContentType(r *http.Request) error {
+	if r.Header.Get(server.HeaderContentType) != server.JsonContentType {
+		return fmt.Errorf(
+			"incorrect Content-Type, required %s, got %s",
+			server.JsonContentType,
+			r.Header.Get(server.HeaderContentType),
+		)
+	}
+
+	return nil
+}
@@ -0,0 +1,238 @@
+package provider
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi"
+
+	"github.com/dink10/enlabs/internal/pkg/auth"
+	"github.com/dink10/enlabs/internal/pkg/logger"
+	"github.com/dink10/enlabs/internal/pkg/payments"
+	"github.com/dink10/enlabs/internal/pkg/server"
+	"github.com/dink10/enlabs/internal/pkg/signature"
+)
+
+// SourceTypesProvider provides endpoints to manage source types.
+type SourceTypesProvider struct {
+	service       *payments.Service
+	signature     *signature.Service
+	authenticator *auth.Authenticator
+	logger        *logger.ProviderLogger
+}
+
+// NewSourceTypesProvider returns a new instance of SourceTypesProvider.
+func NewSourceTypesProvider(
+	service *payments.Service, signature *signature.Service, authenticator *auth.Authenticator,
+) SourceTypesProvider {
+	return SourceTypesProvider{
+		service:       service,
+		signature:     signature,
+		authenticator: authenticator,
+		logger:        logger.NewProviderLogger("source-types"),
+	}
+}
+
+// Router returns SourceTypesProvider router.
+func (p *SourceTypesProvider) Router() http.Handler {
+	r := chi.NewRouter()
+
+	r.Route("/", func(r chi.Router) {
+		r.Use(p.authenticator.Middleware)
+		r.Use(auth.RequireAdmin)
+		r.Get("/", p.list)
+		r.Post("/", p.create)
+		r.Patch("/{sourceTypeID}", p.update)
+		r.Post("/{sourceTypeID}/secrets", p.rotateSecret)
+	})
+
+	return r
+}
+
+// Request body format for creating a source type.
+type sourceTypeRequest struct {
+	Value string `json:"value"`
+}
+
+// Request body format for enabling or disabling a source type.
+type sourceTypeUpdateRequest struct {
+	Enabled *bool `json:"enabled"`
+}
+
+type sourceTypeResponse struct {
+	*server.Response
+	SourceType payments.SourceType `json:"sourceType"`
+}
+
+type secretResponse struct {
+	*server.Response
+	Secret signature.Secret `json:"secret"`
+}
+
+type sourceTypesResponse struct {
+	*server.Response
+	SourceTypes []payments.SourceType `json:"sourceTypes"`
+}
+
+// @Summary Source types
+// @Description All source types, disabled included
+// @ID source-type-list
+// @Tags Source Type
+// @Produce json
+// @Param Authorization header string true "Bearer token of admin"
+// @Success 200 {object} provider.sourceTypesResponse "Source types"
+// @Failure 401 {object} server.ErrorResponse "Unauthorized"
+// @Failure 403 {object} server.ErrorResponse "Forbidden"
+// @Failure 500 {object} server.ErrorResponse "Service Error"
+// @Router /v1/source-types [get]
+func (p *SourceTypesProvider) list(w http.ResponseWriter, r *http.Request) {
+	sourceTypes, err := p.service.SourceTypes(r.Context())
+	if err != nil {
+		p.logger.Logger(r).Error(err)
+		renderServiceError(w, r, err)
+		return
+	}
+
+	server.RenderResponse(w, r, &sourceTypesResponse{
+		Response:    server.NewResponse(http.StatusOK),
+		SourceTypes: sourceTypes,
+	})
+}
+
+// @Summary Create source type
+// @Description Create a new enabled source type. Value may contain lowercase letters, digits, dashes and underscores.
+// @ID source-type-create
+// @Tags Source Type
+// @Accept json
+// @Produce json
+// @Param sourceType body provider.sourceTypeRequest true "Source type to create"
+// @Param Authorization header string true "Bearer token of admin"
+// @Success 201 {object} provider.sourceTypeResponse "Created source type"
+// @Failure 400 {object} server.ErrorResponse "Invalid Request"
+// @Failure 401 {object} server.ErrorResponse "Unauthorized"
+// @Failure 403 {object} server.ErrorResponse "Forbidden"
+// @Failure 409 {object} server.ErrorResponse "Source Type Already Exists"
+// @Failure 500 {object} server.ErrorResponse "Service Error"
+// @Router /v1/source-types [post]
+func (p *SourceTypesProvider) create(w http.ResponseWriter, r *http.Request) {
+	if err := checkContentType(r); err != nil {
+		p.logger.Logger(r).Error(err)
+		server.RenderResponse(w, r, server.NewErrorResponse(http.StatusBadRequest, err))
+		return
+	}
+
+	var request sourceTypeRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		p.logger.Logger(r).Errorf("failed to decode body: %v", err)
+		server.RenderResponse(w, r,
+			server.NewErrorResponse(http.StatusBadRequest, fmt.Errorf("failed to decode request payload")),
+		)
+		return
+	}
+
+	sourceType, err := p.service.CreateSourceType(r.Context(), request.Value)
+	if err != nil {
+		p.logger.Logger(r).Error(err)
+		renderServiceError(w, r, err)
+		return
+	}
+
+	server.RenderResponse(w, r, &sourceTypeResponse{
+		Response:   server.NewResponse(http.StatusCreated),
+		SourceType: sourceType,
+	})
+}
+
+// @Summary Enable or disable source type
+// @Description Payments from disabled source type are rejected, its payments history is kept
+// @ID source-type-update
+// @Tags Source Type
+// @Accept json
+// @Produce json
+// @Param sourceTypeID path int true "Source type ID"
+// @Param sourceType body provider.sourceTypeUpdateRequest true "Whether source type is enabled"
+// @Param Authorization header string true "Bearer token of admin"
+// @Success 200 {object} provider.sourceTypeResponse "Updated source type"
+// @Failure 400 {object} server.ErrorResponse "Invalid Request"
+// @Failure 401 {object} server.ErrorResponse "Unauthorized"
+// @Failure 403 {object} server.ErrorResponse "Forbidden"
+// @Failure 404 {object} server.ErrorResponse "Source Type Not Found"
+// @Failure 500 {object} server.ErrorResponse "Service Error"
+// @Router /v1/source-types/{sourceTypeID} [patch]
+func (p *SourceTypesProvider) update(w http.ResponseWriter, r *http.Request) {
+	sourceTypeID, err := strconv.Atoi(chi.URLParam(r, "sourceTypeID"))
+	if err != nil {
+		p.logger.Logger(r).Errorf("incorrect source type id: %v", err)
+		server.RenderResponse(w, r,
+			server.NewErrorResponse(http.StatusBadRequest, fmt.Errorf("incorrect source type id")),
+		)
+		return
+	}
+
+	if err := checkContentType(r); err != nil {
+		p.logger.Logger(r).Error(err)
+		server.RenderResponse(w, r, server.NewErrorResponse(http.StatusBadRequest, err))
+		return
+	}
+
+	var request sourceTypeUpdateRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil || request.Enabled == nil {
+		p.logger.Logger(r).Errorf("failed to decode body: %v", err)
+		server.RenderResponse(w, r,
+			server.NewErrorResponse(http.StatusBadRequest, fmt.Errorf("failed to decode request payload")),
+		)
+		return
+	}
+
+	sourceType, err := p.service.SetSourceTypeEnabled(r.Context(), sourceTypeID, *request.Enabled)
+	if err != nil {
+		p.logger.Logger(r).Error(err)
+		renderServiceError(w, r, err)
+		return
+	}
+
+	server.RenderResponse(w, r, &sourceTypeResponse{
+		Response:   server.NewResponse(http.StatusOK),
+		SourceType: sourceType,
+	})
+}
+
+// @Summary Rotate source type secret
+// @Description Generate a new secret requests of source type are signed with. The secret is shown only once.
+// @Description Previous secrets stay valid for SIGNATURE_ROTATION_OVERLAP.
+// @ID source-type-rotate-secret
+// @Tags Source Type
+// @Produce json
+// @Param sourceTypeID path int true "Source type ID"
+// @Param Authorization header string true "Bearer token of admin"
+// @Success 201 {object} provider.secretResponse "New secret"
+// @Failure 400 {object} server.ErrorResponse "Invalid Request"
+// @Failure 401 {object} server.ErrorResponse "Unauthorized"
+// @Failure 403 {object} server.ErrorResponse "Forbidden"
+// @Failure 404 {object} server.ErrorResponse "Source Type Not Found"
+// @Failure 500 {object} server.ErrorResponse "Service Error"
+// @Router /v1/source-types/{sourceTypeID}/secrets [post]
+func (p *SourceTypesProvider) rotateSecret(w http.ResponseWriter, r *http.Request) {
+	sourceTypeID, err := strconv.Atoi(chi.URLParam(r, "sourceTypeID"))
+	if err != nil {
+		p.logger.Logger(r).Errorf("incorrect source type id: %v", err)
+		server.RenderResponse(w, r,
+			server.NewErrorResponse(http.StatusBadRequest, fmt.Errorf("incorrect source type id")),
+		)
+		return
+	}
+
+	secret, err := p.signature.RotateSecret(r.Context(), sourceTypeID)
+	if err != nil {
+		p.logger.Logger(r).Error(err)
+		renderServiceError(w, r, err)
+		return
+	}
+
+	server.RenderResponse(w, r, &secretResponse{
+		Response: server.NewResponse(http.StatusCreated),
+		Secret:   secret,
+	})
+}
@@ -0,0 +1,29 @@
+package api
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/dink10/enlabs/internal/pkg/signature"
+)
+
+// purgeNonces deletes nonces which can't be replayed anymore every interval
+// until ctx is cancelled.
+func purgeNonces(ctx context.Context, service *signature.Service, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if err := service.PurgeNonces(ctx); err != nil {
+			logrus.Errorf("failed to purge nonces: %v", err)
+		}
+	}
+}
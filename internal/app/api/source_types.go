@@ -0,0 +1,41 @@
+package api
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-pg/pg/v9"
+	"github.com/sirupsen/logrus"
+
+	"github.com/dink10/enlabs/internal/pkg/payments"
+	"github.com/dink10/enlabs/internal/pkg/payments/storage"
+)
+
+// watchSourceTypes refreshes source types cached by service when DB notifies
+// about their change, and every interval in case a notification was missed
+// while listener reconnected. It returns when ctx is cancelled.
+func watchSourceTypes(ctx context.Context, db *pg.DB, service *payments.Service, interval time.Duration) {
+	listener := db.Listen(storage.SourceTypesChannel)
+	defer func() {
+		if err := listener.Close(); err != nil {
+			logrus.Errorf("failed to close source types listener: %v", err)
+		}
+	}()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	notifications := listener.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-notifications:
+		case <-ticker.C:
+		}
+
+		if err := service.RefreshSourceTypes(ctx); err != nil {
+			logrus.Errorf("failed to refresh source types: %v", err)
+		}
+	}
+}
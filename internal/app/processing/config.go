@@ -3,6 +3,7 @@ package processing
 import (
 	"github.com/dink10/enlabs/internal/pkg/database"
 	"github.com/dink10/enlabs/internal/pkg/logger"
+	"github.com/dink10/enlabs/internal/pkg/payments"
 	"github.com/dink10/enlabs/internal/pkg/processing"
 )
 
@@ -11,4 +12,5 @@ type Config struct {
 	Processing processing.Config
 	Logger     logger.Config
 	Database   database.Config
+	Payments   payments.Config
 }
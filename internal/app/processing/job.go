@@ -0,0 +1,107 @@
+package processing
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/go-pg/pg/v9"
+	"github.com/sirupsen/logrus"
+
+	"github.com/dink10/enlabs/internal/pkg/database"
+	"github.com/dink10/enlabs/internal/pkg/payments"
+	"github.com/dink10/enlabs/internal/pkg/processing"
+)
+
+// Job is a cancellation cycle of post processing. It's run on schedule by
+// processing application and on demand by processing CLI and API.
+type Job struct {
+	db      *pg.DB
+	service *payments.Service
+	runs    *processing.Service
+	policy  processing.Policy
+}
+
+// NewJob returns a new instance of Job.
+func NewJob(db *pg.DB, service *payments.Service, runs *processing.Service, policy processing.Policy) *Job {
+	return &Job{
+		db:      db,
+		service: service,
+		runs:    runs,
+		policy:  policy,
+	}
+}
+
+// RunOnce cancels a batch of payments selected by policy and records the run
+// with outcome of every payment. processing.ErrRunInProgress is returned if
+// another instance holds the lock.
+func (j *Job) RunOnce(ctx context.Context) (processing.Run, error) {
+	release, acquired, err := database.TryAdvisoryLock(ctx, j.db, processing.LockName)
+	if err != nil {
+		return processing.Run{}, err
+	}
+	if !acquired {
+		return processing.Run{}, processing.ErrRunInProgress
+	}
+	defer release()
+
+	logrus.Info("Start of post processing")
+	defer logrus.Info("End of post processing")
+
+	run, err := j.runs.StartRun(ctx)
+	if err != nil {
+		return processing.Run{}, err
+	}
+
+	report, batchErr := j.service.CancelBatch(ctx, j.policy.Selection(time.Now()))
+	if batchErr != nil {
+		run.Error = batchErr.Error()
+	} else {
+		run.Finish(report)
+	}
+
+	// run is recorded even if ctx is cancelled on shutdown
+	if err := j.runs.FinishRun(context.Background(), run); err != nil {
+		logrus.Errorf("query error: %s", err)
+	}
+	if batchErr != nil {
+		return run, batchErr
+	}
+
+	for _, result := range report.Results {
+		switch {
+		case errors.Is(result.Err, payments.ErrPaymentLocked):
+			logrus.Infof("Payment with transaction_id [%s] is locked by another worker, skipping",
+				result.Payment.TransactionID)
+		case result.Err != nil:
+			logrus.Errorf("Payment with transaction_id [%s] can't be cancelled due to: %s",
+				result.Payment.TransactionID, result.Err)
+		default:
+			logrus.Infof("Payment with transaction_id [%s] successfully cancelled", result.Payment.TransactionID)
+		}
+	}
+
+	return run, nil
+}
+
+// DryRun reports what RunOnce would do at the moment: payments which would be
+// cancelled and balances they would leave. Nothing is committed nor recorded.
+func (j *Job) DryRun(ctx context.Context) (processing.Preview, error) {
+	report, err := j.service.PreviewBatch(ctx, j.policy.Selection(time.Now()))
+	if err != nil {
+		return processing.Preview{}, err
+	}
+
+	return processing.NewPreview(report), nil
+}
+
+// run is RunOnce performed on schedule, it's skipped if another instance holds the lock.
+func (j *Job) run(ctx context.Context) {
+	_, err := j.RunOnce(ctx)
+	switch {
+	case errors.Is(err, processing.ErrRunInProgress):
+		logrus.Info("Post processing is run by another instance, skipping")
+	case err != nil:
+		logrus.Errorf("post processing error: %s", err)
+	}
+}
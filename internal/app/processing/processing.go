@@ -2,30 +2,62 @@ package processing
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/go-pg/pg/v9"
-	"github.com/jasonlvhit/gocron"
 	"github.com/sirupsen/logrus"
 
 	"github.com/dink10/enlabs/internal/pkg/config"
 	"github.com/dink10/enlabs/internal/pkg/database"
 	"github.com/dink10/enlabs/internal/pkg/logger"
 	"github.com/dink10/enlabs/internal/pkg/payments"
+	"github.com/dink10/enlabs/internal/pkg/payments/storage"
+	"github.com/dink10/enlabs/internal/pkg/processing"
+	runStorage "github.com/dink10/enlabs/internal/pkg/processing/storage"
 )
 
-// Run runs application.
+// Run runs application: cancellation cycles are performed on schedule until SIGTERM or SIGINT.
 func Run() error {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	var cfg Config
-	if err := config.LoadConfig(&cfg); err != nil {
-		return fmt.Errorf("failed to parse config: %v", err)
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
 	}
 
-	if err := logger.Init(&cfg.Logger); err != nil {
-		return fmt.Errorf("failed to initialize logger: %v", err)
+	db, err := database.Connect(ctx, &cfg.Database)
+	if err != nil {
+		return fmt.Errorf("failed to connect to database: %v", err)
+	}
+	defer database.Close(db)
+
+	job, err := newJob(db, &cfg)
+	if err != nil {
+		return err
+	}
+
+	go cancelOnSignal(cancel)
+
+	return schedule(ctx, &cfg.Processing, job.run)
+}
+
+// RunOnce performs a single cancellation cycle and writes the recorded run to w.
+// With dryRun nothing is committed: payments which would be cancelled and
+// balances they would leave are written instead.
+func RunOnce(w io.Writer, dryRun bool) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
 	}
 
 	db, err := database.Connect(ctx, &cfg.Database)
@@ -34,63 +66,83 @@ func Run() error {
 	}
 	defer database.Close(db)
 
-	err = gocron.Every(cfg.Processing.CancellationTime).Minute().Do(func() {
-		logrus.Info("Start of post processing")
-		defer logrus.Info("End of post processing")
-		var pays []payments.Payment
-		err := db.ModelContext(ctx, &pays).
-			Where("(id % 2) = 1").
-			Where("processed = true").
-			Order("id DESC").
-			Limit(10).
-			Select()
-		if err != nil {
-			logrus.Errorf("query error: %s", err)
-			return
-		}
-
-		for _, p := range pays {
-			err := db.RunInTransaction(func(tx *pg.Tx) error {
-				var account payments.Account
-				query := tx.ModelContext(ctx, &account).
-					Where("id=?", p.AccountID)
-				switch p.State {
-				case "win":
-					query.Where("balance >= ?", p.Amount)
-					query.Set("balance=balance-?", p.Amount)
-				case "lost":
-					query.Set("balance=balance+?", p.Amount)
-				}
-
-				query.Returning("id").Returning("balance")
-
-				if _, err := query.Update(); err != nil {
-					if p.State == "win" && err == pg.ErrNoRows {
-						return fmt.Errorf("insufficient funds")
-					}
-					return fmt.Errorf("no such account")
-				}
-
-				_, err := tx.Model(&p).WherePK().Set("processed = ?", false).Update()
-				if err != nil {
-					return fmt.Errorf("query error: %s", err)
-				}
-
-				return nil
-			})
-			switch {
-			case err != nil:
-				logrus.Errorf("Payment with transaction_id [%s] can't be cancelled due to: %s", p.TransactionID, err)
-			default:
-				logrus.Errorf("Payment with transaction_id [%s] successfully cancelled", p.TransactionID)
-			}
-		}
-	})
+	job, err := newJob(db, &cfg)
+	if err != nil {
+		return err
+	}
+
+	go cancelAfterSignal(cancel, cfg.Processing.ShutdownTimeout)
+
+	var result interface{}
+	if dryRun {
+		result, err = job.DryRun(ctx)
+	} else {
+		result, err = job.RunOnce(ctx)
+	}
 	if err != nil {
 		return err
 	}
 
-	<-gocron.Start()
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(result)
+}
+
+func loadConfig() (Config, error) {
+	var cfg Config
+	if err := config.LoadConfig(&cfg); err != nil {
+		return Config{}, fmt.Errorf("failed to parse config: %v", err)
+	}
+
+	if err := logger.Init(&cfg.Logger); err != nil {
+		return Config{}, fmt.Errorf("failed to initialize logger: %v", err)
+	}
+
+	return cfg, nil
+}
+
+func newJob(db *pg.DB, cfg *Config) (*Job, error) {
+	policy, err := processing.NewPolicy(&cfg.Processing.Policy)
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure cancellation policy: %v", err)
+	}
+
+	paymentService, err := payments.NewService(storage.NewPaymentStorage(db), &cfg.Payments)
+	if err != nil {
+		return nil, fmt.Errorf("failed to init service: %v", err)
+	}
+
+	runService := processing.NewService(runStorage.NewRunStorage(db))
+
+	return NewJob(db, paymentService, runService, policy), nil
+}
+
+func cancelOnSignal(cancelFunc context.CancelFunc) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGTERM, syscall.SIGINT)
+
+	sig := <-signals
+	logrus.Infof("got signal %s, canceling app context", sig)
+
+	cancelFunc()
+}
+
+// cancelAfterSignal cancels context grace period after SIGTERM or SIGINT, so
+// running batch has the same time to finish as the scheduled one. Second
+// signal cancels context at once.
+func cancelAfterSignal(cancelFunc context.CancelFunc, grace time.Duration) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGTERM, syscall.SIGINT)
+
+	sig := <-signals
+	logrus.Infof("got signal %s, canceling app context in %s", sig, grace)
+
+	select {
+	case <-time.After(grace):
+	case sig = <-signals:
+		logrus.Infof("got signal %s, canceling app context", sig)
+	}
 
-	return nil
+	cancelFunc()
 }
@@ -0,0 +1,82 @@
+package processing
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"github.com/sirupsen/logrus"
+
+	"github.com/dink10/enlabs/internal/pkg/processing"
+)
+
+// cronParser accepts standard cron expressions with optional leading seconds
+// field and descriptors such as @daily or @every 30s.
+var cronParser = cron.NewParser(
+	cron.SecondOptional | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor,
+)
+
+// schedule runs job on cfg schedule until ctx is cancelled. A run is skipped
+// if the previous one is still running. After ctx is cancelled no new runs are
+// started and running job is waited for cfg.ShutdownTimeout, after which
+// context of the job is cancelled.
+func schedule(ctx context.Context, cfg *processing.Config, job func(context.Context)) error {
+	spec, err := cfg.Spec()
+	if err != nil {
+		return err
+	}
+
+	location, err := time.LoadLocation(cfg.Timezone)
+	if err != nil {
+		return fmt.Errorf("incorrect timezone %q: %v", cfg.Timezone, err)
+	}
+
+	jobCtx, cancelJob := context.WithCancel(context.Background())
+	defer cancelJob()
+
+	scheduler := cron.New(
+		cron.WithParser(cronParser),
+		cron.WithLocation(location),
+		cron.WithChain(cron.SkipIfStillRunning(cronLogger{})),
+	)
+	if _, err := scheduler.AddFunc(spec, func() { job(jobCtx) }); err != nil {
+		return fmt.Errorf("incorrect schedule %q: %v", spec, err)
+	}
+
+	logrus.Infof("post processing scheduled on %q in %s", spec, location)
+	scheduler.Start()
+
+	<-ctx.Done()
+	done := scheduler.Stop().Done()
+
+	select {
+	case <-done:
+		logrus.Info("post processing stopped")
+	case <-time.After(cfg.ShutdownTimeout):
+		logrus.Errorf("post processing didn't finish in %s, cancelling it", cfg.ShutdownTimeout)
+		cancelJob()
+		<-done
+	}
+
+	return nil
+}
+
+// cronLogger writes scheduler messages, such as skipped overlapping runs, to logrus.
+type cronLogger struct{}
+
+func (cronLogger) Info(msg string, keysAndValues ...interface{}) {
+	logrus.WithFields(cronFields(keysAndValues)).Infof("scheduler: %s", msg)
+}
+
+func (cronLogger) Error(err error, msg string, keysAndValues ...interface{}) {
+	logrus.WithFields(cronFields(keysAndValues)).WithError(err).Errorf("scheduler: %s", msg)
+}
+
+func cronFields(keysAndValues []interface{}) logrus.Fields {
+	fields := make(logrus.Fields, len(keysAndValues)/2)
+	for i := 0; i+1 < len(keysAndValues); i += 2 {
+		fields[fmt.Sprint(keysAndValues[i])] = keysAndValues[i+1]
+	}
+	return fields
+}
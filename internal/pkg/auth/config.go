@@ -0,0 +1,6 @@
+package auth
+
+// Config keeps configuration of caller authentication.
+type Config struct {
+	Secret string `env:"AUTH_SECRET,required"`
+}
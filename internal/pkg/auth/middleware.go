@@ -0,0 +1,65 @@
+package auth
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/dink10/enlabs/internal/pkg/logger"
+	"github.com/dink10/enlabs/internal/pkg/server"
+)
+
+const bearerPrefix = "Bearer "
+
+// Authenticator resolves callers of HTTP endpoints into principals.
+type Authenticator struct {
+	secret string
+	logger *logger.ProviderLogger
+}
+
+// NewAuthenticator returns a new instance of Authenticator.
+func NewAuthenticator(cfg *Config) *Authenticator {
+	return &Authenticator{
+		secret: cfg.Secret,
+		logger: logger.NewProviderLogger("auth"),
+	}
+}
+
+// Middleware puts principal resolved from bearer token into request context.
+// Requests with missing or invalid credentials are rejected with 401.
+func (a *Authenticator) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header := r.Header.Get(server.HeaderAuthorization)
+		if !strings.HasPrefix(header, bearerPrefix) {
+			a.logger.Logger(r).Error("missing bearer token")
+			server.RenderResponse(w, r,
+				server.NewErrorResponse(http.StatusUnauthorized, fmt.Errorf("missing bearer token")),
+			)
+			return
+		}
+
+		principal, err := ParseToken(a.secret, strings.TrimPrefix(header, bearerPrefix))
+		if err != nil {
+			a.logger.Logger(r).Errorf("failed to authenticate: %v", err)
+			server.RenderResponse(w, r, server.NewErrorResponse(http.StatusUnauthorized, err))
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(WithPrincipal(r.Context(), principal)))
+	})
+}
+
+// RequireAdmin rejects requests of principals without admin role with 403.
+func RequireAdmin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal, ok := PrincipalFromContext(r.Context())
+		if !ok || !principal.IsAdmin() {
+			server.RenderResponse(w, r,
+				server.NewErrorResponse(http.StatusForbidden, fmt.Errorf("admin role required")),
+			)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
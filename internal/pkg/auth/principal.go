@@ -0,0 +1,42 @@
+package auth
+
+import "context"
+
+// Role is a role of an authenticated caller.
+type Role string
+
+const (
+	// RolePlayer is a role of a player acting on its own account.
+	RolePlayer Role = "player"
+	// RoleAdmin is a role of trusted back-office services.
+	RoleAdmin Role = "admin"
+)
+
+// Principal is an authenticated caller.
+type Principal struct {
+	AccountID int
+	Role      Role
+}
+
+// IsAdmin reports whether principal has admin role.
+func (p Principal) IsAdmin() bool {
+	return p.Role == RoleAdmin
+}
+
+type principalKey struct{}
+
+// WithPrincipal returns a copy of ctx carrying principal.
+func WithPrincipal(ctx context.Context, principal Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// PrincipalFromContext returns principal stored in ctx.
+func PrincipalFromContext(ctx context.Context) (Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(Principal)
+	return principal, ok
+}
+
+// CanAccess reports whether principal may act on account with given id.
+func (p Principal) CanAccess(accountID int) bool {
+	return p.IsAdmin() || p.AccountID == accountID
+}
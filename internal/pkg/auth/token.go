@@ -0,0 +1,127 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const algorithm = "HS256"
+
+var (
+	// ErrInvalidToken is returned when token can't be parsed or its signature is wrong.
+	ErrInvalidToken = errors.New("invalid token")
+	// ErrExpiredToken is returned when token is expired.
+	ErrExpiredToken = errors.New("token is expired")
+)
+
+type header struct {
+	Alg string `json:"alg"`
+	Typ string `json:"typ"`
+}
+
+type claims struct {
+	Subject   string `json:"sub,omitempty"`
+	Role      Role   `json:"role"`
+	IssuedAt  int64  `json:"iat"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+// NewToken returns HMAC-signed JWT for principal which expires after ttl.
+func NewToken(secret string, principal Principal, ttl time.Duration) (string, error) {
+	now := time.Now()
+	c := claims{
+		Role:      principal.Role,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(ttl).Unix(),
+	}
+	if principal.AccountID != 0 {
+		c.Subject = strconv.Itoa(principal.AccountID)
+	}
+
+	h, err := encodeSegment(header{Alg: algorithm, Typ: "JWT"})
+	if err != nil {
+		return "", err
+	}
+	payload, err := encodeSegment(c)
+	if err != nil {
+		return "", err
+	}
+
+	unsigned := h + "." + payload
+
+	return unsigned + "." + sign(secret, unsigned), nil
+}
+
+// ParseToken verifies HMAC-signed JWT and returns principal it was issued for.
+func ParseToken(secret, token string) (Principal, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return Principal{}, ErrInvalidToken
+	}
+
+	var h header
+	if err := decodeSegment(parts[0], &h); err != nil || h.Alg != algorithm {
+		return Principal{}, ErrInvalidToken
+	}
+
+	expected := sign(secret, parts[0]+"."+parts[1])
+	if !hmac.Equal([]byte(expected), []byte(parts[2])) {
+		return Principal{}, ErrInvalidToken
+	}
+
+	var c claims
+	if err := decodeSegment(parts[1], &c); err != nil {
+		return Principal{}, ErrInvalidToken
+	}
+
+	if c.ExpiresAt == 0 || time.Now().Unix() >= c.ExpiresAt {
+		return Principal{}, ErrExpiredToken
+	}
+
+	principal := Principal{Role: c.Role}
+	switch c.Role {
+	case RoleAdmin:
+	case RolePlayer:
+		id, err := strconv.Atoi(c.Subject)
+		if err != nil || id <= 0 {
+			return Principal{}, ErrInvalidToken
+		}
+		principal.AccountID = id
+	default:
+		return Principal{}, ErrInvalidToken
+	}
+
+	return principal, nil
+}
+
+func sign(secret, unsigned string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	_, _ = mac.Write([]byte(unsigned))
+
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func encodeSegment(v interface{}) (string, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode token: %v", err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func decodeSegment(segment string, v interface{}) error {
+	b, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(b, v)
+}
@@ -0,0 +1,40 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-pg/pg/v9"
+	"github.com/sirupsen/logrus"
+)
+
+// TryAdvisoryLock tries to acquire session-level postgres advisory lock
+// identified by name without waiting. If lock is acquired, returned function
+// must be called to release it. Lock is held by a dedicated connection,
+// so it's released by postgres as well when the process dies.
+func TryAdvisoryLock(ctx context.Context, db *pg.DB, name string) (func(), bool, error) {
+	conn := db.Conn()
+
+	var acquired bool
+	_, err := conn.QueryOneContext(ctx, pg.Scan(&acquired), "SELECT pg_try_advisory_lock(hashtext(?))", name)
+	if err != nil || !acquired {
+		if closeErr := conn.Close(); closeErr != nil {
+			logrus.Errorf("failed to close connection: %v", closeErr)
+		}
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to acquire advisory lock %q: %v", name, err)
+		}
+		return nil, false, nil
+	}
+
+	release := func() {
+		if _, err := conn.Exec("SELECT pg_advisory_unlock(hashtext(?))", name); err != nil {
+			logrus.Errorf("failed to release advisory lock %q: %v", name, err)
+		}
+		if err := conn.Close(); err != nil {
+			logrus.Errorf("failed to close connection: %v", err)
+		}
+	}
+
+	return release, true, nil
+}
@@ -6,7 +6,10 @@ import (
 )
 
 // Config is an application config.
+// SignatureOptOut is temporary: existing source types without secret keep
+// accepting unsigned requests until their first secret is issued.
 type Config struct {
-	Logger   logger.Config
-	Database database.Config
+	Logger          logger.Config
+	Database        database.Config
+	SignatureOptOut bool `env:"MIGRATE_SIGNATURE_OPT_OUT"`
 }
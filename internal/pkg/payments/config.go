@@ -0,0 +1,13 @@
+package payments
+
+import "time"
+
+// Config keeps configuration of payments.
+// SourceTypesRefresh is an interval of reloading cached source types,
+// in addition to reloading on change notifications. LimitCoolingOff is
+// a delay before raise of an account limit takes effect.
+type Config struct {
+	DeficitPolicy      string        `env:"DEFICIT_POLICY" envDefault:"retry"`
+	SourceTypesRefresh time.Duration `env:"SOURCE_TYPES_REFRESH" envDefault:"1m"`
+	LimitCoolingOff    time.Duration `env:"LIMIT_COOLING_OFF" envDefault:"24h"`
+}
@@ -0,0 +1,84 @@
+package payments
+
+import (
+	"fmt"
+	"time"
+)
+
+// DeficitPolicy defines what happens when cancellation would make balance negative.
+type DeficitPolicy string
+
+// Deficit policies.
+const (
+	// DeficitNegative applies cancellation and lets balance go negative.
+	DeficitNegative DeficitPolicy = "negative"
+	// DeficitDebt takes what balance allows and records the rest as a debt
+	// collected from future wins.
+	DeficitDebt DeficitPolicy = "debt"
+	// DeficitRetry parks cancellation in cancel_failed status, parked
+	// cancellations are re-attempted by later runs of post processing.
+	DeficitRetry DeficitPolicy = "retry"
+)
+
+// ParseDeficitPolicy returns DeficitPolicy by its name.
+func ParseDeficitPolicy(s string) (DeficitPolicy, error) {
+	switch policy := DeficitPolicy(s); policy {
+	case DeficitNegative, DeficitDebt, DeficitRetry:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("incorrect deficit policy %q, expected negative, debt or retry", s)
+	}
+}
+
+// Deficit is an outcome of deficit policy for reversal of a payment.
+// Delta is applied to balance, Debt is recorded for the amount balance didn't cover.
+type Deficit struct {
+	Delta  Money
+	Debt   Money
+	Reason string
+}
+
+// Apply returns Deficit of reversal changing balance by delta.
+// Policy applies only if reversal takes more than balance has, reversal
+// raising balance is applied as is even if balance is negative.
+// ErrInsufficientFunds is returned when reversal must be retried later.
+func (p DeficitPolicy) Apply(balance, delta Money) (Deficit, error) {
+	if delta >= 0 {
+		return Deficit{Delta: delta}, nil
+	}
+
+	remaining, err := balance.Add(delta)
+	if err != nil {
+		return Deficit{}, err
+	}
+	if remaining >= 0 {
+		return Deficit{Delta: delta}, nil
+	}
+
+	switch p {
+	case DeficitNegative:
+		return Deficit{Delta: delta, Reason: fmt.Sprintf("balance went negative by %s", remaining.Abs())}, nil
+	case DeficitDebt:
+		covered := balance
+		if covered.IsNegative() {
+			covered = 0
+		}
+		debt := -delta - covered
+		return Deficit{Delta: -covered, Debt: debt, Reason: fmt.Sprintf("debt of %s recorded", debt)}, nil
+	default:
+		return Deficit{}, ErrInsufficientFunds
+	}
+}
+
+// Debt is an amount of cancelled payment which balance didn't cover.
+// It's collected from future wins in payment currency.
+type Debt struct {
+	ID        int        `json:"id" pg:",pk"`
+	CreatedAt time.Time  `json:"createdAt"`
+	AccountID int        `json:"accountId"`
+	Currency  string     `json:"currency"`
+	PaymentID int        `json:"paymentId"`
+	Amount    Money      `json:"amount" swaggertype:"string"`
+	Remaining Money      `json:"remaining" swaggertype:"string"`
+	SettledAt *time.Time `json:"settledAt,omitempty"`
+}
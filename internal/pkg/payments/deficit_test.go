@@ -0,0 +1,108 @@
+package payments
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestDeficitPolicyApply(t *testing.T) {
+	testSuite := []struct {
+		testName    string
+		policy      DeficitPolicy
+		balance     Money
+		delta       Money
+		expected    Deficit
+		expectedErr error
+	}{
+		{
+			testName: "Balance covers reversal",
+			policy:   DeficitRetry,
+			balance:  10 * moneyFactor,
+			delta:    -10 * moneyFactor,
+			expected: Deficit{Delta: -10 * moneyFactor},
+		},
+		{
+			testName: "Reversal raises balance",
+			policy:   DeficitRetry,
+			balance:  5 * moneyFactor,
+			delta:    3 * moneyFactor,
+			expected: Deficit{Delta: 3 * moneyFactor},
+		},
+		{
+			testName: "Reversal raises negative balance with retry policy",
+			policy:   DeficitRetry,
+			balance:  -5 * moneyFactor,
+			delta:    3 * moneyFactor,
+			expected: Deficit{Delta: 3 * moneyFactor},
+		},
+		{
+			testName: "Reversal raises negative balance with debt policy",
+			policy:   DeficitDebt,
+			balance:  -5 * moneyFactor,
+			delta:    3 * moneyFactor,
+			expected: Deficit{Delta: 3 * moneyFactor},
+		},
+		{
+			testName: "Reversal raises negative balance with negative policy",
+			policy:   DeficitNegative,
+			balance:  -5 * moneyFactor,
+			delta:    3 * moneyFactor,
+			expected: Deficit{Delta: 3 * moneyFactor},
+		},
+		{
+			testName:    "Retry reversal above balance",
+			policy:      DeficitRetry,
+			balance:     4 * moneyFactor,
+			delta:       -10 * moneyFactor,
+			expectedErr: ErrInsufficientFunds,
+		},
+		{
+			testName: "Let balance go negative",
+			policy:   DeficitNegative,
+			balance:  4 * moneyFactor,
+			delta:    -10 * moneyFactor,
+			expected: Deficit{Delta: -10 * moneyFactor, Reason: "balance went negative by 6.00"},
+		},
+		{
+			testName: "Record debt for uncovered amount",
+			policy:   DeficitDebt,
+			balance:  4 * moneyFactor,
+			delta:    -10 * moneyFactor,
+			expected: Deficit{Delta: -4 * moneyFactor, Debt: 6 * moneyFactor, Reason: "debt of 6.00 recorded"},
+		},
+		{
+			testName: "Record debt for whole amount on empty balance",
+			policy:   DeficitDebt,
+			balance:  0,
+			delta:    -10 * moneyFactor,
+			expected: Deficit{Debt: 10 * moneyFactor, Reason: "debt of 10.00 recorded"},
+		},
+		{
+			testName: "Record debt for whole amount on negative balance",
+			policy:   DeficitDebt,
+			balance:  -2 * moneyFactor,
+			delta:    -10 * moneyFactor,
+			expected: Deficit{Debt: 10 * moneyFactor, Reason: "debt of 10.00 recorded"},
+		},
+		{
+			testName:    "Reversal overflowing balance",
+			policy:      DeficitDebt,
+			balance:     math.MinInt64 + 1,
+			delta:       -10 * moneyFactor,
+			expectedErr: ErrMoneyOverflow,
+		},
+	}
+
+	for _, ts := range testSuite {
+		t.Run(ts.testName, func(t *testing.T) {
+			deficit, err := ts.policy.Apply(ts.balance, ts.delta)
+			if !errors.Is(err, ts.expectedErr) {
+				t.Fatalf("wrong error: expected: %v, actual: %v", ts.expectedErr, err)
+			}
+			if deficit != ts.expected {
+				t.Errorf("wrong deficit: expected: %+v, actual: %+v", ts.expected, deficit)
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package payments
+
+// Error is a payments domain error with a stable machine-readable code.
+type Error struct {
+	Code    string
+	Message string
+}
+
+// Error implements error interface.
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// ErrorCode returns machine-readable code of the error.
+func (e *Error) ErrorCode() string {
+	return e.Code
+}
+
+var (
+	// ErrAccountNotFound is returned when account doesn't exist.
+	ErrAccountNotFound = &Error{Code: "account_not_found", Message: "account not found"}
+	// ErrAccountInactive is returned for payments of blocked or closed account.
+	ErrAccountInactive = &Error{Code: "account_inactive", Message: "account is not active"}
+	// ErrAccountClosed is returned on attempt to change status of closed account.
+	ErrAccountClosed = &Error{Code: "account_closed", Message: "account is closed"}
+	// ErrInvalidAccountStatus is returned for account statuses other than active, blocked and closed.
+	ErrInvalidAccountStatus = &Error{Code: "invalid_account_status", Message: "invalid account status"}
+	// ErrAccountExcluded is returned for payments of self-excluded account.
+	ErrAccountExcluded = &Error{Code: "account_excluded", Message: "account is self-excluded"}
+	// ErrExclusionActive is returned on attempt to shorten or lift active self-exclusion.
+	ErrExclusionActive = &Error{Code: "exclusion_active", Message: "active self-exclusion can only be extended"}
+	// ErrInvalidExclusion is returned for negative self-exclusion period.
+	ErrInvalidExclusion = &Error{Code: "invalid_exclusion", Message: "invalid self-exclusion period"}
+	// ErrPaymentNotFound is returned when payment doesn't exist.
+	ErrPaymentNotFound = &Error{Code: "payment_not_found", Message: "payment not found"}
+	// ErrPaymentCancelled is returned on attempt to cancel payment twice.
+	ErrPaymentCancelled = &Error{Code: "payment_cancelled", Message: "payment already cancelled"}
+	// ErrPaymentNotProcessed is returned on attempt to cancel payment which wasn't applied.
+	ErrPaymentNotProcessed = &Error{Code: "payment_not_processed", Message: "payment was not processed"}
+	// ErrDuplicateTransaction is returned when transaction id is resubmitted with a different payload.
+	ErrDuplicateTransaction = &Error{
+		Code:    "duplicate_transaction",
+		Message: "transaction_id already processed with different payload",
+	}
+	// ErrPaymentLocked is returned when payment is being cancelled by another worker.
+	ErrPaymentLocked = &Error{Code: "payment_locked", Message: "payment is being processed by another worker"}
+	// ErrInvalidTransition is returned on transition forbidden by payment lifecycle.
+	ErrInvalidTransition = &Error{Code: "invalid_status_transition", Message: "invalid payment status transition"}
+	// ErrInsufficientFunds is returned when payment would make balance negative.
+	ErrInsufficientFunds = &Error{Code: "insufficient_funds", Message: "insufficient funds"}
+	// ErrUnknownSourceType is returned for source types absent in source_types table.
+	ErrUnknownSourceType = &Error{Code: "unknown_source_type", Message: "wrong header Source-Type"}
+	// ErrSourceTypeDisabled is returned for payments from disabled source types.
+	ErrSourceTypeDisabled = &Error{Code: "source_type_disabled", Message: "source type is disabled"}
+	// ErrSourceTypeNotFound is returned when source type with given id doesn't exist.
+	ErrSourceTypeNotFound = &Error{Code: "source_type_not_found", Message: "source type not found"}
+	// ErrDuplicateSourceType is returned when source type with given value already exists.
+	ErrDuplicateSourceType = &Error{Code: "duplicate_source_type", Message: "source type already exists"}
+	// ErrInvalidSourceType is returned for source type values other than lowercase
+	// letters, digits, dashes and underscores.
+	ErrInvalidSourceType = &Error{Code: "invalid_source_type", Message: "invalid source type value"}
+	// ErrUnsupportedCurrency is returned for currencies absent in currencies table.
+	ErrUnsupportedCurrency = &Error{Code: "unsupported_currency", Message: "unsupported currency"}
+	// ErrCurrencyRequired is returned when account is created without currencies.
+	ErrCurrencyRequired = &Error{Code: "currency_required", Message: "at least one currency is required"}
+	// ErrInvalidLimitKind is returned for limit kinds other than daily, weekly and monthly loss and max stake.
+	ErrInvalidLimitKind = &Error{Code: "invalid_limit_kind", Message: "invalid limit kind"}
+	// ErrCurrencyMismatch is returned when account has no balance in payment currency.
+	ErrCurrencyMismatch = &Error{Code: "currency_mismatch", Message: "account has no balance in payment currency"}
+)
@@ -0,0 +1,69 @@
+package payments
+
+import "time"
+
+// Exclusion is a self-exclusion state of an account. Excluded account can't
+// proceed payments, its accepted payments still can be cancelled.
+type Exclusion struct {
+	Excluded  bool       `json:"excluded"`
+	From      *time.Time `json:"from,omitempty"`
+	Until     *time.Time `json:"until,omitempty"`
+	Permanent bool       `json:"permanent"`
+}
+
+// ExclusionChange is a record of audit history of account self-exclusion.
+// Change without ExcludedUntil is a permanent exclusion.
+type ExclusionChange struct {
+	tableName struct{} `pg:"account_exclusion_changes"` // nolint
+
+	ID             int        `json:"id" pg:",pk"`
+	CreatedAt      time.Time  `json:"createdAt"`
+	AccountID      int        `json:"-"`
+	ExcludedFrom   time.Time  `json:"excludedFrom"`
+	ExcludedUntil  *time.Time `json:"excludedUntil,omitempty"`
+	ActorRole      string     `json:"actorRole"`
+	ActorAccountID int        `json:"actorAccountId,omitempty"`
+}
+
+// Excluded reports whether account is self-excluded at now.
+func (a Account) Excluded(now time.Time) bool {
+	return a.ExcludedFrom != nil && !now.Before(*a.ExcludedFrom) &&
+		(a.ExcludedUntil == nil || now.Before(*a.ExcludedUntil))
+}
+
+// Exclusion returns self-exclusion state of account at now.
+func (a Account) Exclusion(now time.Time) Exclusion {
+	if !a.Excluded(now) {
+		return Exclusion{}
+	}
+
+	return Exclusion{
+		Excluded:  true,
+		From:      a.ExcludedFrom,
+		Until:     a.ExcludedUntil,
+		Permanent: a.ExcludedUntil == nil,
+	}
+}
+
+// Exclude self-excludes account from now for period, zero period excludes it
+// permanently. Active exclusion can only be extended, ErrExclusionActive is
+// returned on attempt to shorten it.
+func (a *Account) Exclude(now time.Time, period time.Duration) error {
+	var until *time.Time
+	if period > 0 {
+		end := now.Add(period)
+		until = &end
+	}
+
+	if !a.Excluded(now) {
+		a.ExcludedFrom, a.ExcludedUntil = &now, until
+		return nil
+	}
+
+	if a.ExcludedUntil == nil || (until != nil && !until.After(*a.ExcludedUntil)) {
+		return ErrExclusionActive
+	}
+	a.ExcludedUntil = until
+
+	return nil
+}
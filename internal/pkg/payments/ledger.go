@@ -0,0 +1,75 @@
+package payments
+
+import "time"
+
+// LedgerAccount is an account of the double-entry ledger.
+type LedgerAccount string
+
+// Ledger accounts. Wallet keeps money owed to a player, house is its counterparty.
+const (
+	LedgerWallet LedgerAccount = "wallet"
+	LedgerHouse  LedgerAccount = "house"
+)
+
+// EntryKind is a kind of operation ledger entries were posted for.
+type EntryKind string
+
+// Entry kinds.
+const (
+	EntryOpening      EntryKind = "opening"
+	EntryPayment      EntryKind = "payment"
+	EntryCancellation EntryKind = "cancellation"
+	// EntryDebtCollection is a payment of a debt left by cancellation.
+	EntryDebtCollection EntryKind = "debt_collection"
+)
+
+// LedgerEntry is a ledger entry model. Exactly one of Debit and Credit is positive.
+type LedgerEntry struct {
+	ID        int64         `json:"id" pg:",pk"`
+	CreatedAt time.Time     `json:"createdAt"`
+	PaymentID int           `json:"paymentId"`
+	AccountID int           `json:"accountId"`
+	Currency  string        `json:"currency"`
+	Ledger    LedgerAccount `json:"ledger"`
+	Kind      EntryKind     `json:"kind"`
+	Debit     Money         `json:"debit" swaggertype:"string"`
+	Credit    Money         `json:"credit" swaggertype:"string"`
+}
+
+// BalanceMismatch is an account balance which differs from the sum of its wallet entries.
+type BalanceMismatch struct {
+	AccountID     int    `json:"accountId"`
+	Currency      string `json:"currency"`
+	Balance       Money  `json:"balance" swaggertype:"string"`
+	LedgerBalance Money  `json:"ledgerBalance" swaggertype:"string"`
+}
+
+// WalletDelta returns amount payment changes player's wallet by.
+func (p Payment) WalletDelta() Money {
+	if p.State == "lost" {
+		return -p.Amount
+	}
+	return p.Amount
+}
+
+// Postings returns balanced pair of entries moving delta into player's
+// wallet from the house, or out of it if delta is negative.
+func Postings(payment Payment, kind EntryKind, delta Money) []LedgerEntry {
+	wallet := LedgerEntry{
+		PaymentID: payment.ID,
+		AccountID: payment.AccountID,
+		Currency:  payment.Currency,
+		Ledger:    LedgerWallet,
+		Kind:      kind,
+	}
+	house := wallet
+	house.Ledger = LedgerHouse
+
+	if delta.IsNegative() {
+		wallet.Debit, house.Credit = delta.Abs(), delta.Abs()
+	} else {
+		wallet.Credit, house.Debit = delta, delta
+	}
+
+	return []LedgerEntry{wallet, house}
+}
@@ -0,0 +1,153 @@
+package payments
+
+import (
+	"fmt"
+	"time"
+)
+
+// LimitKind is a kind of responsible gaming limit of an account.
+type LimitKind string
+
+// Limit kinds. Loss limits cap sum of lost payments within a rolling window,
+// stake limit caps amount of a single lost payment.
+const (
+	LimitDailyLoss   LimitKind = "daily_loss"
+	LimitWeeklyLoss  LimitKind = "weekly_loss"
+	LimitMonthlyLoss LimitKind = "monthly_loss"
+	LimitMaxStake    LimitKind = "max_stake"
+)
+
+// Rolling windows of loss limits.
+const (
+	DailyWindow   = 24 * time.Hour
+	WeeklyWindow  = 7 * DailyWindow
+	MonthlyWindow = 30 * DailyWindow
+)
+
+// ParseLimitKind parses limit kind, ErrInvalidLimitKind is returned for unknown kinds.
+func ParseLimitKind(s string) (LimitKind, error) {
+	switch kind := LimitKind(s); kind {
+	case LimitDailyLoss, LimitWeeklyLoss, LimitMonthlyLoss, LimitMaxStake:
+		return kind, nil
+	default:
+		return "", fmt.Errorf("%w %q", ErrInvalidLimitKind, s)
+	}
+}
+
+// AccountLimit is a limit of an account in a currency. Zero amount doesn't limit.
+// Raise of the limit is pending until PendingFrom, lowering is applied at once.
+type AccountLimit struct {
+	AccountID     int        `json:"-" pg:",pk"`
+	Currency      string     `json:"currency" pg:",pk"`
+	Kind          LimitKind  `json:"kind" pg:",pk"`
+	Amount        Money      `json:"amount" swaggertype:"string" pg:",use_zero"`
+	PendingAmount *Money     `json:"pendingAmount,omitempty" swaggertype:"string"`
+	PendingFrom   *time.Time `json:"pendingFrom,omitempty"`
+	UpdatedAt     time.Time  `json:"updatedAt"`
+}
+
+// Effective returns amount of the limit in force at now.
+func (l AccountLimit) Effective(now time.Time) Money {
+	if l.PendingAmount != nil && l.PendingFrom != nil && !now.Before(*l.PendingFrom) {
+		return *l.PendingAmount
+	}
+	return l.Amount
+}
+
+// Change sets amount of the limit at now. Raise, including removal of the
+// limit with zero amount, takes effect after coolingOff, replacing pending
+// raise if any. Lowering takes effect at once and drops pending raise.
+func (l *AccountLimit) Change(amount Money, now time.Time, coolingOff time.Duration) {
+	l.Amount = l.Effective(now)
+	l.PendingAmount, l.PendingFrom = nil, nil
+
+	if !raises(l.Amount, amount) || coolingOff <= 0 {
+		l.Amount = amount
+		return
+	}
+
+	from := now.Add(coolingOff)
+	l.PendingAmount, l.PendingFrom = &amount, &from
+}
+
+// raises reports whether changing limit from current to amount loosens it.
+func raises(current, amount Money) bool {
+	switch {
+	case current == 0:
+		return false
+	case amount == 0:
+		return true
+	default:
+		return amount > current
+	}
+}
+
+// Losses are sums of amounts of lost payments of an account in a currency
+// applied within rolling windows ending now.
+type Losses struct {
+	Daily   Money
+	Weekly  Money
+	Monthly Money
+}
+
+// AccountLimits are limits of an account in a currency.
+type AccountLimits []AccountLimit
+
+// Check returns *LimitExceededError if lost payment breaks a limit in force
+// at now given losses of the account before the payment. Wins aren't limited.
+func (ls AccountLimits) Check(payment Payment, losses Losses, now time.Time) error {
+	if payment.WalletDelta() >= 0 {
+		return nil
+	}
+
+	for _, l := range ls {
+		limit := l.Effective(now)
+		if limit == 0 {
+			continue
+		}
+
+		var loss Money
+		switch l.Kind {
+		case LimitDailyLoss:
+			loss = losses.Daily
+		case LimitWeeklyLoss:
+			loss = losses.Weekly
+		case LimitMonthlyLoss:
+			loss = losses.Monthly
+		}
+
+		value, err := payment.Amount.Add(loss)
+		if err != nil {
+			return fmt.Errorf("failed to sum %s losses: %w", l.Kind, err)
+		}
+
+		if value > limit {
+			return &LimitExceededError{Limit: l.Kind, Amount: limit.String(), Value: value.String()}
+		}
+	}
+
+	return nil
+}
+
+// LimitExceededError is returned when payment exceeds a limit of its account.
+// Value is the stake or the loss including the payment checked against Amount.
+type LimitExceededError struct {
+	Limit  LimitKind `json:"limit"`
+	Amount string    `json:"amount"`
+	Value  string    `json:"value"`
+}
+
+// Error implements error interface.
+func (e *LimitExceededError) Error() string {
+	return fmt.Sprintf("payment exceeds %s limit of account: %s, limit %s", e.Limit, e.Value, e.Amount)
+}
+
+// ErrorCode returns machine-readable code of the error.
+func (e *LimitExceededError) ErrorCode() string {
+	return "limit_exceeded"
+}
+
+// ErrorDetails returns the exceeded limit.
+func (e *LimitExceededError) ErrorDetails() interface{} {
+	return e
+}
@@ -0,0 +1,229 @@
+package payments
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+const (
+	// moneyScale is a number of decimal places Money is kept with.
+	moneyScale = 8
+	// moneyFactor is 10^moneyScale.
+	moneyFactor = 100000000
+	// minStringPlaces is a minimal number of decimal places Money is formatted with.
+	minStringPlaces = 2
+)
+
+var (
+	// ErrInvalidMoney is returned when string is not a decimal number.
+	ErrInvalidMoney = errors.New("invalid decimal number")
+	// ErrMoneyOverflow is returned when amount doesn't fit into Money.
+	ErrMoneyOverflow = errors.New("amount is out of range")
+	// ErrTooManyPlaces is returned when amount has more decimal places than allowed.
+	ErrTooManyPlaces = errors.New("too many decimal places")
+)
+
+// Money is an exact fixed-point monetary amount.
+// It keeps number of 10^-8 fractions of a unit, so any amount with up to
+// 8 decimal places is represented without rounding.
+type Money int64
+
+// ParseMoney parses decimal string like "-12.34" into Money.
+// It returns ErrTooManyPlaces if s has more than places decimal places.
+func ParseMoney(s string, places int) (Money, error) {
+	if places > moneyScale {
+		places = moneyScale
+	}
+
+	negative := strings.HasPrefix(s, "-")
+	if negative {
+		s = s[1:]
+	}
+
+	intPart, fracPart := s, ""
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		intPart, fracPart = s[:i], s[i+1:]
+		if fracPart == "" {
+			return 0, ErrInvalidMoney
+		}
+	}
+	if intPart == "" || !isDigits(intPart) || !isDigits(fracPart) {
+		return 0, ErrInvalidMoney
+	}
+
+	fracPart = strings.TrimRight(fracPart, "0")
+	if len(fracPart) > places {
+		return 0, fmt.Errorf("%w: at most %d allowed", ErrTooManyPlaces, places)
+	}
+
+	units, err := strconv.ParseInt(intPart, 10, 64)
+	if err != nil || units > math.MaxInt64/moneyFactor {
+		return 0, ErrMoneyOverflow
+	}
+
+	var fraction int64
+	if fracPart != "" {
+		fraction, err = strconv.ParseInt(fracPart+strings.Repeat("0", moneyScale-len(fracPart)), 10, 64)
+		if err != nil {
+			return 0, ErrInvalidMoney
+		}
+	}
+
+	m := Money(units*moneyFactor + fraction)
+	if m < 0 {
+		return 0, ErrMoneyOverflow
+	}
+	if negative {
+		m = -m
+	}
+
+	return m, nil
+}
+
+// Add returns m + other. ErrMoneyOverflow is returned if the sum doesn't fit into Money.
+func (m Money) Add(other Money) (Money, error) {
+	if (other > 0 && m > math.MaxInt64-other) || (other < 0 && m < math.MinInt64-other) {
+		return 0, ErrMoneyOverflow
+	}
+	return m + other, nil
+}
+
+// Sub returns m - other. ErrMoneyOverflow is returned if the difference doesn't fit into Money.
+func (m Money) Sub(other Money) (Money, error) {
+	if (other < 0 && m > math.MaxInt64+other) || (other > 0 && m < math.MinInt64+other) {
+		return 0, ErrMoneyOverflow
+	}
+	return m - other, nil
+}
+
+// IsNegative reports whether m is less than zero.
+func (m Money) IsNegative() bool {
+	return m < 0
+}
+
+// Abs returns absolute value of m.
+func (m Money) Abs() Money {
+	if m < 0 {
+		return -m
+	}
+	return m
+}
+
+// Places returns number of significant decimal places of m.
+func (m Money) Places() int {
+	fraction := int64(m.Abs() % moneyFactor)
+	if fraction == 0 {
+		return 0
+	}
+
+	places := moneyScale
+	for fraction%10 == 0 {
+		fraction /= 10
+		places--
+	}
+
+	return places
+}
+
+// String returns m as a decimal string with at least two decimal places.
+func (m Money) String() string {
+	places := m.Places()
+	if places < minStringPlaces {
+		places = minStringPlaces
+	}
+
+	return m.Format(places)
+}
+
+// Format returns m as a decimal string with exactly places decimal places.
+// Extra places are truncated.
+func (m Money) Format(places int) string {
+	if places > moneyScale {
+		places = moneyScale
+	}
+
+	var b strings.Builder
+	if m < 0 {
+		b.WriteByte('-')
+	}
+
+	abs := uint64(m)
+	if m < 0 {
+		abs = uint64(-m)
+	}
+
+	b.WriteString(strconv.FormatUint(abs/moneyFactor, 10))
+	if places > 0 {
+		fraction := strconv.FormatUint(abs%moneyFactor+moneyFactor, 10)[1:]
+		b.WriteByte('.')
+		b.WriteString(fraction[:places])
+	}
+
+	return b.String()
+}
+
+// MarshalJSON implements json.Marshaler. Money is encoded as a string
+// to keep it exact for clients parsing numbers as floats.
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Quote(m.String())), nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler. Both strings and numbers are accepted.
+func (m *Money) UnmarshalJSON(b []byte) error {
+	s := string(b)
+	if unquoted, err := strconv.Unquote(s); err == nil {
+		s = unquoted
+	}
+
+	v, err := ParseMoney(s, moneyScale)
+	if err != nil {
+		return fmt.Errorf("failed to parse money %s: %w", b, err)
+	}
+	*m = v
+
+	return nil
+}
+
+// Value implements driver.Valuer.
+func (m Money) Value() (driver.Value, error) {
+	return m.String(), nil
+}
+
+// Scan implements sql.Scanner.
+func (m *Money) Scan(src interface{}) error {
+	var s string
+	switch v := src.(type) {
+	case nil:
+		*m = 0
+		return nil
+	case []byte:
+		s = string(v)
+	case string:
+		s = v
+	case int64:
+		s = strconv.FormatInt(v, 10)
+	default:
+		return fmt.Errorf("failed to scan money from %T", src)
+	}
+
+	v, err := ParseMoney(s, moneyScale)
+	if err != nil {
+		return fmt.Errorf("failed to scan money %q: %w", s, err)
+	}
+	*m = v
+
+	return nil
+}
+
+func isDigits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,204 @@
+package payments
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestParseMoney(t *testing.T) {
+	testSuite := []struct {
+		testName    string
+		value       string
+		places      int
+		expected    Money
+		expectedErr error
+	}{
+		{testName: "Integer", value: "12", places: 2, expected: 12 * moneyFactor},
+		{testName: "Decimal", value: "12.34", places: 2, expected: 1234000000},
+		{testName: "Negative", value: "-12.34", places: 2, expected: -1234000000},
+		{testName: "Fewer places than allowed", value: "0.1", places: 2, expected: 10000000},
+		{testName: "Trailing zeros", value: "1.2300", places: 2, expected: 123000000},
+		{testName: "Zero", value: "0.00", places: 2, expected: 0},
+		{testName: "Smallest fraction", value: "0.00000001", places: 8, expected: 1},
+		{testName: "Places above scale", value: "0.00000001", places: 12, expected: 1},
+		{testName: "Largest amount", value: "92233720368.54775807", places: 8, expected: 9223372036854775807},
+		{testName: "Too many places", value: "1.234", places: 2, expectedErr: ErrTooManyPlaces},
+		{testName: "Too many places above scale", value: "0.000000001", places: 12, expectedErr: ErrTooManyPlaces},
+		{testName: "Integer overflow", value: "92233720369", places: 2, expectedErr: ErrMoneyOverflow},
+		{testName: "Fraction overflow", value: "92233720368.99999999", places: 8, expectedErr: ErrMoneyOverflow},
+		{testName: "Empty", value: "", places: 2, expectedErr: ErrInvalidMoney},
+		{testName: "Trailing point", value: "1.", places: 2, expectedErr: ErrInvalidMoney},
+		{testName: "Leading point", value: ".5", places: 2, expectedErr: ErrInvalidMoney},
+		{testName: "Plus sign", value: "+1", places: 2, expectedErr: ErrInvalidMoney},
+		{testName: "Double minus", value: "--1", places: 2, expectedErr: ErrInvalidMoney},
+		{testName: "Exponent", value: "1e3", places: 2, expectedErr: ErrInvalidMoney},
+		{testName: "Letters", value: "ten", places: 2, expectedErr: ErrInvalidMoney},
+		{testName: "Two points", value: "1.2.3", places: 2, expectedErr: ErrInvalidMoney},
+	}
+
+	for _, ts := range testSuite {
+		t.Run(ts.testName, func(t *testing.T) {
+			m, err := ParseMoney(ts.value, ts.places)
+			if !errors.Is(err, ts.expectedErr) {
+				t.Fatalf("wrong error: expected: %v, actual: %v", ts.expectedErr, err)
+			}
+			if m != ts.expected {
+				t.Errorf("wrong money: expected: %d, actual: %d", ts.expected, m)
+			}
+		})
+	}
+}
+
+func TestMoneyArithmetic(t *testing.T) {
+	const maxMoney, minMoney = Money(math.MaxInt64), Money(math.MinInt64)
+
+	testSuite := []struct {
+		testName    string
+		operation   func(a, b Money) (Money, error)
+		a, b        Money
+		expected    Money
+		expectedErr error
+	}{
+		{testName: "Add", operation: Money.Add, a: 12 * moneyFactor, b: 30 * moneyFactor, expected: 42 * moneyFactor},
+		{testName: "Add negative", operation: Money.Add, a: 12 * moneyFactor, b: -30 * moneyFactor, expected: -18 * moneyFactor},
+		{testName: "Add up to largest amount", operation: Money.Add, a: maxMoney - 1, b: 1, expected: maxMoney},
+		{testName: "Add above largest amount", operation: Money.Add, a: maxMoney, b: 1, expectedErr: ErrMoneyOverflow},
+		{testName: "Add below smallest amount", operation: Money.Add, a: minMoney, b: -1, expectedErr: ErrMoneyOverflow},
+		{testName: "Sub", operation: Money.Sub, a: 12 * moneyFactor, b: 30 * moneyFactor, expected: -18 * moneyFactor},
+		{testName: "Sub negative", operation: Money.Sub, a: 12 * moneyFactor, b: -30 * moneyFactor, expected: 42 * moneyFactor},
+		{testName: "Sub down to smallest amount", operation: Money.Sub, a: minMoney + 1, b: 1, expected: minMoney},
+		{testName: "Sub below smallest amount", operation: Money.Sub, a: minMoney, b: 1, expectedErr: ErrMoneyOverflow},
+		{testName: "Sub above largest amount", operation: Money.Sub, a: maxMoney, b: -1, expectedErr: ErrMoneyOverflow},
+	}
+
+	for _, ts := range testSuite {
+		t.Run(ts.testName, func(t *testing.T) {
+			m, err := ts.operation(ts.a, ts.b)
+			if !errors.Is(err, ts.expectedErr) {
+				t.Fatalf("wrong error: expected: %v, actual: %v", ts.expectedErr, err)
+			}
+			if m != ts.expected {
+				t.Errorf("wrong money: expected: %d, actual: %d", ts.expected, m)
+			}
+		})
+	}
+}
+
+func TestMoneyString(t *testing.T) {
+	testSuite := []struct {
+		testName       string
+		money          Money
+		places         int
+		expectedString string
+		expectedFormat string
+	}{
+		{testName: "Zero", money: 0, places: 0, expectedString: "0.00", expectedFormat: "0"},
+		{testName: "Integer", money: 5 * moneyFactor, places: 2, expectedString: "5.00", expectedFormat: "5.00"},
+		{testName: "One place", money: 10000000, places: 2, expectedString: "0.10", expectedFormat: "0.10"},
+		{testName: "Two places", money: 1234000000, places: 1, expectedString: "12.34", expectedFormat: "12.3"},
+		{testName: "Eight places", money: 123456789, places: 8, expectedString: "1.23456789", expectedFormat: "1.23456789"},
+		{testName: "Places above scale", money: 1, places: 10, expectedString: "0.00000001", expectedFormat: "0.00000001"},
+		{testName: "Negative", money: -50000000, places: 2, expectedString: "-0.50", expectedFormat: "-0.50"},
+		{testName: "Negative truncated", money: -123456789, places: 2, expectedString: "-1.23456789", expectedFormat: "-1.23"},
+	}
+
+	for _, ts := range testSuite {
+		t.Run(ts.testName, func(t *testing.T) {
+			if s := ts.money.String(); s != ts.expectedString {
+				t.Errorf("wrong string: expected: %s, actual: %s", ts.expectedString, s)
+			}
+			if s := ts.money.Format(ts.places); s != ts.expectedFormat {
+				t.Errorf("wrong format: expected: %s, actual: %s", ts.expectedFormat, s)
+			}
+
+			parsed, err := ParseMoney(ts.money.String(), moneyScale)
+			if err != nil || parsed != ts.money {
+				t.Errorf("string doesn't round-trip: %d, err: %v", parsed, err)
+			}
+		})
+	}
+}
+
+func TestMoneyJSON(t *testing.T) {
+	testSuite := []struct {
+		testName    string
+		json        string
+		expected    Money
+		expectedErr bool
+	}{
+		{testName: "String", json: `"12.34"`, expected: 1234000000},
+		{testName: "Number", json: `12.34`, expected: 1234000000},
+		{testName: "Negative string", json: `"-0.00000001"`, expected: -1},
+		{testName: "Too many places", json: `"0.000000001"`, expectedErr: true},
+		{testName: "Not a number", json: `"ten"`, expectedErr: true},
+		{testName: "Exponent", json: `1e3`, expectedErr: true},
+	}
+
+	for _, ts := range testSuite {
+		t.Run(ts.testName, func(t *testing.T) {
+			var m Money
+			err := json.Unmarshal([]byte(ts.json), &m)
+			if ts.expectedErr != (err != nil) {
+				t.Fatalf("wrong error: expected error: %t, actual: %v", ts.expectedErr, err)
+			}
+			if err != nil {
+				return
+			}
+			if m != ts.expected {
+				t.Errorf("wrong money: expected: %d, actual: %d", ts.expected, m)
+			}
+
+			b, err := json.Marshal(m)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var roundTrip Money
+			if err := json.Unmarshal(b, &roundTrip); err != nil || roundTrip != m {
+				t.Errorf("json doesn't round-trip: %s, err: %v", b, err)
+			}
+		})
+	}
+}
+
+func TestMoneyScan(t *testing.T) {
+	testSuite := []struct {
+		testName    string
+		src         interface{}
+		expected    Money
+		expectedErr bool
+	}{
+		{testName: "Null", src: nil, expected: 0},
+		{testName: "Numeric bytes", src: []byte("12.34000000"), expected: 1234000000},
+		{testName: "Numeric string", src: "-0.50", expected: -50000000},
+		{testName: "Integer", src: int64(5), expected: 5 * moneyFactor},
+		{testName: "More places than scale", src: []byte("0.000000001"), expectedErr: true},
+		{testName: "Float", src: 1.5, expectedErr: true},
+	}
+
+	for _, ts := range testSuite {
+		t.Run(ts.testName, func(t *testing.T) {
+			m := Money(42)
+			err := m.Scan(ts.src)
+			if ts.expectedErr != (err != nil) {
+				t.Fatalf("wrong error: expected error: %t, actual: %v", ts.expectedErr, err)
+			}
+			if err != nil {
+				return
+			}
+			if m != ts.expected {
+				t.Errorf("wrong money: expected: %d, actual: %d", ts.expected, m)
+			}
+
+			v, err := m.Value()
+			if err != nil {
+				t.Fatal(err)
+			}
+			var roundTrip Money
+			if err := roundTrip.Scan(v); err != nil || roundTrip != m {
+				t.Errorf("value doesn't round-trip: %v, err: %v", v, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package payments
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Rules of source types.
+const (
+	RuleAllowedStates  = "allowed_states"
+	RuleMinAmount      = "min_amount"
+	RuleMaxAmount      = "max_amount"
+	RuleMaxDailyVolume = "max_daily_volume"
+)
+
+// SourceRules are rules payments of a source type in a currency must follow.
+// Empty AllowedStates allow any state, zero amounts don't limit. Daily volume
+// is a sum of amounts of payments applied since the start of the current day.
+type SourceRules struct {
+	AllowedStates  []string
+	MinAmount      Money
+	MaxAmount      Money
+	MaxDailyVolume Money
+}
+
+// Check returns *RuleViolationError if payment breaks state or amount rules.
+// Daily volume isn't checked, it depends on payments applied before.
+func (r SourceRules) Check(payment Payment) error {
+	if len(r.AllowedStates) > 0 && !contains(r.AllowedStates, payment.State) {
+		return &RuleViolationError{
+			Rule:  RuleAllowedStates,
+			Limit: strings.Join(r.AllowedStates, ","),
+			Value: payment.State,
+		}
+	}
+	if r.MinAmount > 0 && payment.Amount < r.MinAmount {
+		return &RuleViolationError{Rule: RuleMinAmount, Limit: r.MinAmount.String(), Value: payment.Amount.String()}
+	}
+	if r.MaxAmount > 0 && payment.Amount > r.MaxAmount {
+		return &RuleViolationError{Rule: RuleMaxAmount, Limit: r.MaxAmount.String(), Value: payment.Amount.String()}
+	}
+
+	return nil
+}
+
+// CheckDailyVolume returns *RuleViolationError if payment makes daily volume
+// of the source type exceed the limit.
+func (r SourceRules) CheckDailyVolume(payment Payment, volume Money) error {
+	if r.MaxDailyVolume <= 0 {
+		return nil
+	}
+
+	total, err := volume.Add(payment.Amount)
+	if err != nil {
+		return fmt.Errorf("failed to sum daily volume: %w", err)
+	}
+	if total > r.MaxDailyVolume {
+		return &RuleViolationError{Rule: RuleMaxDailyVolume, Limit: r.MaxDailyVolume.String(), Value: total.String()}
+	}
+
+	return nil
+}
+
+// RuleViolationError is returned when payment breaks a rule of its source type.
+// Value is the value of the payment checked against Limit of the Rule.
+type RuleViolationError struct {
+	Rule  string `json:"rule"`
+	Limit string `json:"limit"`
+	Value string `json:"value"`
+}
+
+// Error implements error interface.
+func (e *RuleViolationError) Error() string {
+	return fmt.Sprintf("payment violates %s rule of source type: %s, limit %s", e.Rule, e.Value, e.Limit)
+}
+
+// ErrorCode returns machine-readable code of the error.
+func (e *RuleViolationError) ErrorCode() string {
+	return "rule_violation"
+}
+
+// ErrorDetails returns the violated rule.
+func (e *RuleViolationError) ErrorDetails() interface{} {
+	return e
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,77 @@
+package payments
+
+import (
+	"errors"
+	"time"
+)
+
+// Parity is a parity of payment id.
+type Parity string
+
+// Parities of payment id.
+const (
+	ParityAny  Parity = ""
+	ParityOdd  Parity = "odd"
+	ParityEven Parity = "even"
+)
+
+// Selection narrows accepted payments picked for cancellation.
+// Zero fields don't filter. SamplePercent picks roughly given percent
+// of matching payments at random.
+type Selection struct {
+	Parity        Parity
+	SamplePercent int
+	CreatedFrom   time.Time
+	CreatedTo     time.Time
+	SourceTypes   []string
+	States        []string
+	Limit         int
+}
+
+// CancelOptions tunes cancellation of a payment.
+// With SkipLocked payment locked by another transaction isn't waited for,
+// ErrPaymentLocked is returned instead.
+type CancelOptions struct {
+	DeficitPolicy DeficitPolicy
+	SkipLocked    bool
+}
+
+// CancelResult is an outcome of cancellation of a payment.
+type CancelResult struct {
+	Payment Payment
+	Err     error
+}
+
+// CancelReport is an outcome of cancellation of a batch of payments.
+// Balances are reported by dry run only: balances the cancellations would leave.
+type CancelReport struct {
+	Results  []CancelResult
+	Balances []Balance
+}
+
+// Cancelled returns number of cancelled payments.
+func (r CancelReport) Cancelled() int {
+	var n int
+	for _, result := range r.Results {
+		if result.Err == nil {
+			n++
+		}
+	}
+	return n
+}
+
+// Skipped returns number of payments locked by another worker.
+func (r CancelReport) Skipped() int {
+	var n int
+	for _, result := range r.Results {
+		if errors.Is(result.Err, ErrPaymentLocked) {
+			n++
+		}
+	}
+	return n
+}
+
+// Failed returns number of payments which weren't cancelled nor skipped.
+func (r CancelReport) Failed() int {
+	return len(r.Results) - r.Cancelled() - r.Skipped()
+}
@@ -2,60 +2,492 @@ package payments
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"regexp"
+	"sync"
+	"time"
 )
 
 // Storage defines user service's storage interface.
 type Storage interface {
 	SourceTypes(context.Context) ([]SourceType, error)
-	ProceedPayment(context.Context, Payment) error
-	Balance(context.Context) (Account, error)
+	CreateSourceType(context.Context, SourceType) (SourceType, error)
+	SetSourceTypeEnabled(context.Context, int, bool) (SourceType, error)
+	Currencies(context.Context) ([]Currency, error)
+	ProceedPayment(context.Context, Payment) (Payment, error)
+	SourceRules(context.Context, int, string) (SourceRules, error)
+	AccountLimits(context.Context, int) ([]AccountLimit, error)
+	SetAccountLimit(context.Context, AccountLimit, time.Duration) (AccountLimit, error)
+	ExcludeAccount(context.Context, int, time.Duration) (Account, error)
+	ExclusionChanges(context.Context, int) ([]ExclusionChange, error)
+	Payments(context.Context, PaymentFilter) ([]Payment, error)
+	Payment(context.Context, string) (Payment, error)
+	CancelPayment(context.Context, string, CancelOptions) (Payment, error)
+	CancellationCandidates(context.Context, Selection) ([]Payment, error)
+	PreviewCancellations(context.Context, []Payment, CancelOptions) (CancelReport, error)
+	ParkedCancellations(context.Context, int) ([]Payment, error)
+	Balances(context.Context) ([]Balance, error)
+	CreateAccount(context.Context, Account) (Account, error)
+	Account(context.Context, int) (Account, error)
+	SetAccountStatus(context.Context, int, AccountStatus) (Account, error)
+	Accounts(context.Context, Page) ([]Account, int, error)
+	LedgerMismatches(context.Context) ([]BalanceMismatch, error)
 }
 
+const (
+	defaultPageLimit = 20
+	maxPageLimit     = 100
+)
+
+var sourceTypePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
 // Service implements user functionality.
 type Service struct {
-	storage     Storage
-	sourceTypes map[string]int
+	storage         Storage
+	deficitPolicy   DeficitPolicy
+	limitCoolingOff time.Duration
+	currencies      map[string]Currency
+
+	mu          sync.RWMutex
+	sourceTypes map[string]SourceType
 }
 
 // NewService returns a new instance of Service.
-func NewService(storage Storage) (*Service, error) {
-	sourceTypes, err := storage.SourceTypes(context.Background())
+func NewService(storage Storage, cfg *Config) (*Service, error) {
+	deficitPolicy, err := ParseDeficitPolicy(cfg.DeficitPolicy)
+	if err != nil {
+		return nil, err
+	}
+
+	currencies, err := storage.Currencies(context.Background())
 	if err != nil {
 		return nil, err
 	}
 
 	s := Service{
-		storage:     storage,
-		sourceTypes: make(map[string]int),
+		storage:         storage,
+		deficitPolicy:   deficitPolicy,
+		limitCoolingOff: cfg.LimitCoolingOff,
+		currencies:      make(map[string]Currency),
 	}
 
-	for _, v := range sourceTypes {
-		s.sourceTypes[v.Value] = v.ID
+	for _, v := range currencies {
+		s.currencies[v.Code] = v
+	}
+
+	if err := s.RefreshSourceTypes(context.Background()); err != nil {
+		return nil, err
 	}
 
 	return &s, nil
 }
 
-// SourceTypeID returns all source types
-func (s *Service) SourceTypeID(_ context.Context, sourceType string) (int, error) {
-	id, ok := s.sourceTypes[sourceType]
+// SourceTypeID returns id of enabled source type.
+func (s *Service) SourceTypeID(ctx context.Context, sourceType string) (int, error) {
+	v, err := s.SourceType(ctx, sourceType)
+	if err != nil {
+		return 0, err
+	}
+	if !v.Enabled {
+		return 0, ErrSourceTypeDisabled
+	}
+
+	return v.ID, nil
+}
+
+// SourceType returns cached source type by value, disabled source types included.
+func (s *Service) SourceType(_ context.Context, sourceType string) (SourceType, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	v, ok := s.sourceTypes[sourceType]
 	if !ok {
-		return 0, fmt.Errorf("wrong header Source-Type")
+		return SourceType{}, ErrUnknownSourceType
+	}
+
+	return v, nil
+}
+
+// SourceTypes returns all source types.
+func (s *Service) SourceTypes(ctx context.Context) ([]SourceType, error) {
+	return s.storage.SourceTypes(ctx)
+}
+
+// CreateSourceType creates a new enabled source type.
+func (s *Service) CreateSourceType(ctx context.Context, value string) (SourceType, error) {
+	if !sourceTypePattern.MatchString(value) {
+		return SourceType{}, ErrInvalidSourceType
+	}
+
+	sourceType, err := s.storage.CreateSourceType(ctx, SourceType{Value: value, Enabled: true})
+	if err != nil {
+		return SourceType{}, err
+	}
+
+	if err := s.RefreshSourceTypes(ctx); err != nil {
+		return SourceType{}, fmt.Errorf("failed to refresh source types: %w", err)
+	}
+
+	return sourceType, nil
+}
+
+// SetSourceTypeEnabled enables or disables source type by id.
+func (s *Service) SetSourceTypeEnabled(ctx context.Context, id int, enabled bool) (SourceType, error) {
+	sourceType, err := s.storage.SetSourceTypeEnabled(ctx, id, enabled)
+	if err != nil {
+		return SourceType{}, err
+	}
+
+	if err := s.RefreshSourceTypes(ctx); err != nil {
+		return SourceType{}, fmt.Errorf("failed to refresh source types: %w", err)
 	}
 
-	return id, nil
+	return sourceType, nil
 }
 
-// ProceedPayment processes payments
-func (s *Service) ProceedPayment(ctx context.Context, payment Payment) error {
-	if err := s.storage.ProceedPayment(ctx, payment); err != nil {
-		return fmt.Errorf("failed to proceed payment: %w", err)
+// RefreshSourceTypes reloads cached source types from storage. Source types
+// are changed by any API replica, so every replica refreshes its cache on
+// change notifications and periodically.
+func (s *Service) RefreshSourceTypes(ctx context.Context) error {
+	sourceTypes, err := s.storage.SourceTypes(ctx)
+	if err != nil {
+		return err
 	}
+
+	cache := make(map[string]SourceType, len(sourceTypes))
+	for _, v := range sourceTypes {
+		cache[v.Value] = v
+	}
+
+	s.mu.Lock()
+	s.sourceTypes = cache
+	s.mu.Unlock()
+
 	return nil
 }
 
-// Balance returns account balance
-func (s *Service) Balance(ctx context.Context) (Account, error) {
-	return s.storage.Balance(ctx)
+// Currency returns supported currency by ISO code.
+func (s *Service) Currency(_ context.Context, code string) (Currency, error) {
+	currency, ok := s.currencies[code]
+	if !ok {
+		return Currency{}, fmt.Errorf("%w %q", ErrUnsupportedCurrency, code)
+	}
+
+	return currency, nil
+}
+
+// ProceedPayment processes payments and returns the recorded payment.
+// Payment breaking rules of its source type is refused with
+// *RuleViolationError before balance is touched.
+// Lost payment exceeding a limit of its account is rejected with
+// *LimitExceededError, any payment of inactive or self-excluded account is
+// rejected with ErrAccountInactive or ErrAccountExcluded.
+// Rejected payment is retried when its transaction id is resubmitted with
+// the same payload. Other payments aren't applied twice: if payload is the
+// same, original payment is returned with replayed flag set, otherwise
+// ErrDuplicateTransaction is returned.
+func (s *Service) ProceedPayment(ctx context.Context, payment Payment) (Payment, bool, error) {
+	if err := s.checkRules(ctx, payment); err != nil {
+		if original, ok := s.replayed(ctx, payment); ok {
+			return original, true, nil
+		}
+		return Payment{}, false, fmt.Errorf("failed to proceed payment: %w", err)
+	}
+
+	proceeded, err := s.storage.ProceedPayment(ctx, payment)
+	if err == nil {
+		return proceeded, false, nil
+	}
+	if !errors.Is(err, ErrDuplicateTransaction) {
+		return Payment{}, false, fmt.Errorf("failed to proceed payment: %w", err)
+	}
+
+	original, err := s.storage.Payment(ctx, payment.TransactionID)
+	if err != nil {
+		return Payment{}, false, fmt.Errorf("failed to proceed payment: %w", err)
+	}
+
+	if !original.SamePayload(payment) {
+		return Payment{}, false, fmt.Errorf("failed to proceed payment: %w", ErrDuplicateTransaction)
+	}
+
+	return original, true, nil
+}
+
+// checkRules returns *RuleViolationError if payment breaks state or amount
+// rules of its source type. Daily volume is checked by storage, which
+// serializes payments counted in the same volume.
+func (s *Service) checkRules(ctx context.Context, payment Payment) error {
+	rules, err := s.storage.SourceRules(ctx, payment.SourceType, payment.Currency)
+	if err != nil {
+		return err
+	}
+
+	return rules.Check(payment)
+}
+
+// replayed returns original payment if payment resubmits applied transaction
+// with the same payload. Such resubmission isn't checked against rules again.
+func (s *Service) replayed(ctx context.Context, payment Payment) (Payment, bool) {
+	original, err := s.storage.Payment(ctx, payment.TransactionID)
+	if err != nil || original.Status == PaymentRejected || !original.SamePayload(payment) {
+		return Payment{}, false
+	}
+
+	return original, true
+}
+
+// AccountLimits returns limits of account, including pending raises.
+func (s *Service) AccountLimits(ctx context.Context, accountID int) ([]AccountLimit, error) {
+	if _, err := s.storage.Account(ctx, accountID); err != nil {
+		return nil, err
+	}
+
+	return s.storage.AccountLimits(ctx, accountID)
+}
+
+// SetAccountLimit sets limit of kind of account in currency, zero amount
+// removes the limit. Lowering takes effect at once, raise and removal take
+// effect after cooling-off delay.
+func (s *Service) SetAccountLimit(
+	ctx context.Context, accountID int, kind, currency string, amount Money,
+) (AccountLimit, error) {
+	limitKind, err := ParseLimitKind(kind)
+	if err != nil {
+		return AccountLimit{}, err
+	}
+
+	cur, err := s.Currency(ctx, currency)
+	if err != nil {
+		return AccountLimit{}, err
+	}
+
+	if _, err := s.storage.Account(ctx, accountID); err != nil {
+		return AccountLimit{}, err
+	}
+
+	limit := AccountLimit{AccountID: accountID, Currency: cur.Code, Kind: limitKind, Amount: amount}
+
+	return s.storage.SetAccountLimit(ctx, limit, s.limitCoolingOff)
+}
+
+// Exclusion returns self-exclusion state of account.
+func (s *Service) Exclusion(ctx context.Context, accountID int) (Exclusion, error) {
+	account, err := s.storage.Account(ctx, accountID)
+	if err != nil {
+		return Exclusion{}, err
+	}
+
+	return account.Exclusion(time.Now()), nil
+}
+
+// ExcludeAccount self-excludes account for period, zero period excludes it
+// permanently. Active exclusion can only be extended. Every change is
+// recorded in audit history of the account.
+func (s *Service) ExcludeAccount(ctx context.Context, accountID int, period time.Duration) (Exclusion, error) {
+	if period < 0 {
+		return Exclusion{}, ErrInvalidExclusion
+	}
+
+	account, err := s.storage.ExcludeAccount(ctx, accountID, period)
+	if err != nil {
+		return Exclusion{}, err
+	}
+
+	return account.Exclusion(time.Now()), nil
+}
+
+// ExclusionHistory returns audit history of account self-exclusion, the latest first.
+func (s *Service) ExclusionHistory(ctx context.Context, accountID int) ([]ExclusionChange, error) {
+	if _, err := s.storage.Account(ctx, accountID); err != nil {
+		return nil, err
+	}
+
+	return s.storage.ExclusionChanges(ctx, accountID)
+}
+
+// Payments returns a page of payments matching filter and cursor of the next
+// page, which is zero for the last page.
+func (s *Service) Payments(ctx context.Context, filter PaymentFilter) ([]Payment, int, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = defaultPageLimit
+	}
+	if filter.Limit > maxPageLimit {
+		filter.Limit = maxPageLimit
+	}
+
+	limit := filter.Limit
+	filter.Limit++
+
+	pays, err := s.storage.Payments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if len(pays) <= limit {
+		return pays, 0, nil
+	}
+
+	pays = pays[:limit]
+
+	return pays, pays[limit-1].ID, nil
+}
+
+// Payment returns payment by transaction id.
+func (s *Service) Payment(ctx context.Context, transactionID string) (Payment, error) {
+	return s.storage.Payment(ctx, transactionID)
+}
+
+// CancelPayment reverses balance change made by accepted payment.
+// Payment is reversed at most once, repeated cancellation returns ErrPaymentCancelled.
+// If balance doesn't cover reversal, configured DeficitPolicy is applied.
+func (s *Service) CancelPayment(ctx context.Context, transactionID string) (Payment, error) {
+	return s.cancelPayment(ctx, transactionID, CancelOptions{DeficitPolicy: s.deficitPolicy})
+}
+
+func (s *Service) cancelPayment(ctx context.Context, transactionID string, opts CancelOptions) (Payment, error) {
+	payment, err := s.storage.CancelPayment(ctx, transactionID, opts)
+	if err != nil {
+		return Payment{}, fmt.Errorf("failed to cancel payment: %w", err)
+	}
+
+	return payment, nil
+}
+
+// CancellationCandidates returns accepted payments matching selection, the latest first.
+func (s *Service) CancellationCandidates(ctx context.Context, selection Selection) ([]Payment, error) {
+	return s.storage.CancellationCandidates(ctx, selection)
+}
+
+// CancelBatch re-attempts parked cancellations and cancels payments matching
+// selection one by one. Failure to cancel a payment doesn't stop the batch,
+// it's reported in the result of the payment. Payments locked by another
+// worker are skipped with ErrPaymentLocked. Batch stops when ctx is done,
+// payments not attempted yet are left for the next batch.
+func (s *Service) CancelBatch(ctx context.Context, selection Selection) (CancelReport, error) {
+	candidates, err := s.batchCandidates(ctx, selection)
+	if err != nil {
+		return CancelReport{}, err
+	}
+
+	opts := CancelOptions{DeficitPolicy: s.deficitPolicy, SkipLocked: true}
+
+	report := CancelReport{Results: make([]CancelResult, 0, len(candidates))}
+	for _, candidate := range candidates {
+		if ctx.Err() != nil {
+			break
+		}
+
+		payment, err := s.cancelPayment(ctx, candidate.TransactionID, opts)
+		if err != nil {
+			payment = candidate
+		}
+		report.Results = append(report.Results, CancelResult{Payment: payment, Err: err})
+	}
+
+	return report, nil
+}
+
+// PreviewBatch reports what CancelBatch would do with the same selection,
+// including balances left by the cancellations, without committing any change.
+func (s *Service) PreviewBatch(ctx context.Context, selection Selection) (CancelReport, error) {
+	candidates, err := s.batchCandidates(ctx, selection)
+	if err != nil {
+		return CancelReport{}, err
+	}
+
+	opts := CancelOptions{DeficitPolicy: s.deficitPolicy, SkipLocked: true}
+
+	report, err := s.storage.PreviewCancellations(ctx, candidates, opts)
+	if err != nil {
+		return CancelReport{}, fmt.Errorf("failed to preview cancellations: %w", err)
+	}
+
+	return report, nil
+}
+
+// batchCandidates returns parked cancellations followed by payments matching selection.
+// Parked cancellations take their share of selection limit first.
+func (s *Service) batchCandidates(ctx context.Context, selection Selection) ([]Payment, error) {
+	parked, err := s.storage.ParkedCancellations(ctx, selection.Limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to select parked cancellations: %w", err)
+	}
+	if len(parked) >= selection.Limit {
+		return parked[:selection.Limit], nil
+	}
+	selection.Limit -= len(parked)
+
+	candidates, err := s.storage.CancellationCandidates(ctx, selection)
+	if err != nil {
+		return nil, fmt.Errorf("failed to select payments: %w", err)
+	}
+
+	return append(parked, candidates...), nil
+}
+
+// Balances returns account balances in every currency.
+func (s *Service) Balances(ctx context.Context) ([]Balance, error) {
+	return s.storage.Balances(ctx)
+}
+
+// CreateAccount creates a new active account with zero balances in given currencies.
+func (s *Service) CreateAccount(ctx context.Context, currencies []string) (Account, error) {
+	if len(currencies) == 0 {
+		return Account{}, ErrCurrencyRequired
+	}
+
+	account := Account{Status: AccountActive}
+	seen := make(map[string]bool, len(currencies))
+	for _, code := range currencies {
+		currency, err := s.Currency(ctx, code)
+		if err != nil {
+			return Account{}, err
+		}
+		if seen[currency.Code] {
+			continue
+		}
+		seen[currency.Code] = true
+
+		account.Balances = append(account.Balances, Balance{Currency: currency.Code})
+	}
+
+	return s.storage.CreateAccount(ctx, account)
+}
+
+// Account returns account by id.
+func (s *Service) Account(ctx context.Context, id int) (Account, error) {
+	return s.storage.Account(ctx, id)
+}
+
+// SetAccountStatus changes status of account. Closed status is final,
+// ErrAccountClosed is returned on attempt to change it.
+func (s *Service) SetAccountStatus(ctx context.Context, id int, status string) (Account, error) {
+	accountStatus, err := ParseAccountStatus(status)
+	if err != nil {
+		return Account{}, err
+	}
+
+	return s.storage.SetAccountStatus(ctx, id, accountStatus)
+}
+
+// Accounts returns a page of accounts ordered by id and total number of accounts.
+func (s *Service) Accounts(ctx context.Context, page Page) ([]Account, int, error) {
+	if page.Limit <= 0 {
+		page.Limit = defaultPageLimit
+	}
+	if page.Limit > maxPageLimit {
+		page.Limit = maxPageLimit
+	}
+	if page.Offset < 0 {
+		page.Offset = 0
+	}
+
+	return s.storage.Accounts(ctx, page)
+}
+
+// LedgerMismatches returns account balances which can't be derived from the ledger.
+func (s *Service) LedgerMismatches(ctx context.Context) ([]BalanceMismatch, error) {
+	return s.storage.LedgerMismatches(ctx)
 }
@@ -0,0 +1,1152 @@
+package payments
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeStorage is an in-memory Storage keeping payments by transaction id.
+// Methods not needed by tests return zero values.
+type fakeStorage struct {
+	payments     map[string]Payment
+	candidates   []Payment
+	selectErr    error
+	cancelErrs   map[string]error
+	proceedErr   error
+	lastSelected Selection
+	lastOpts     CancelOptions
+	onCancel     func()
+	parked       []Payment
+	locked       map[string]bool
+	sourceTypes  []SourceType
+	rules        map[int]SourceRules
+	volume       Money
+	accounts     map[int]Account
+	limits       []AccountLimit
+	coolingOff   time.Duration
+	exclusions   []ExclusionChange
+}
+
+func newFakeStorage(pays ...Payment) *fakeStorage {
+	s := &fakeStorage{
+		payments:   make(map[string]Payment),
+		cancelErrs: make(map[string]error),
+		locked:     make(map[string]bool),
+		accounts:   make(map[int]Account),
+		sourceTypes: []SourceType{
+			{ID: 1, Value: "game", Enabled: true},
+			{ID: 3, Value: "payment", Enabled: true},
+		},
+	}
+	for _, p := range pays {
+		s.payments[p.TransactionID] = p
+		s.candidates = append(s.candidates, p)
+	}
+	return s
+}
+
+func (s *fakeStorage) SourceTypes(context.Context) ([]SourceType, error) {
+	return append([]SourceType(nil), s.sourceTypes...), nil
+}
+
+func (s *fakeStorage) CreateSourceType(_ context.Context, sourceType SourceType) (SourceType, error) {
+	for _, v := range s.sourceTypes {
+		if v.Value == sourceType.Value {
+			return SourceType{}, ErrDuplicateSourceType
+		}
+	}
+	sourceType.ID = len(s.sourceTypes) + 10
+	s.sourceTypes = append(s.sourceTypes, sourceType)
+	return sourceType, nil
+}
+
+func (s *fakeStorage) SetSourceTypeEnabled(_ context.Context, id int, enabled bool) (SourceType, error) {
+	for i := range s.sourceTypes {
+		if s.sourceTypes[i].ID == id {
+			s.sourceTypes[i].Enabled = enabled
+			return s.sourceTypes[i], nil
+		}
+	}
+	return SourceType{}, ErrSourceTypeNotFound
+}
+
+func (s *fakeStorage) Currencies(context.Context) ([]Currency, error) {
+	return []Currency{{Code: "EUR", Places: 2}}, nil
+}
+
+func (s *fakeStorage) ProceedPayment(_ context.Context, payment Payment) (Payment, error) {
+	if s.proceedErr != nil {
+		return Payment{}, s.proceedErr
+	}
+	if _, ok := s.payments[payment.TransactionID]; ok {
+		return Payment{}, ErrDuplicateTransaction
+	}
+	if err := s.rules[payment.SourceType].CheckDailyVolume(payment, s.volume); err != nil {
+		return Payment{}, err
+	}
+	payment.ID = len(s.payments) + 100
+	payment.CreatedAt = time.Now()
+	payment.Status = PaymentAccepted
+	s.payments[payment.TransactionID] = payment
+	return payment, nil
+}
+
+func (s *fakeStorage) SourceRules(_ context.Context, sourceTypeID int, _ string) (SourceRules, error) {
+	return s.rules[sourceTypeID], nil
+}
+
+func (s *fakeStorage) AccountLimits(_ context.Context, accountID int) ([]AccountLimit, error) {
+	var limits []AccountLimit
+	for _, l := range s.limits {
+		if l.AccountID == accountID {
+			limits = append(limits, l)
+		}
+	}
+	return limits, nil
+}
+
+func (s *fakeStorage) SetAccountLimit(
+	_ context.Context, limit AccountLimit, coolingOff time.Duration,
+) (AccountLimit, error) {
+	s.coolingOff = coolingOff
+	for i, l := range s.limits {
+		if l.AccountID == limit.AccountID && l.Currency == limit.Currency && l.Kind == limit.Kind {
+			s.limits[i].Change(limit.Amount, time.Now(), coolingOff)
+			return s.limits[i], nil
+		}
+	}
+	s.limits = append(s.limits, limit)
+	return limit, nil
+}
+
+func (s *fakeStorage) ExcludeAccount(_ context.Context, accountID int, period time.Duration) (Account, error) {
+	account, ok := s.accounts[accountID]
+	if !ok {
+		return Account{}, ErrAccountNotFound
+	}
+	if err := account.Exclude(time.Now(), period); err != nil {
+		return Account{}, err
+	}
+	s.accounts[accountID] = account
+	s.exclusions = append([]ExclusionChange{{
+		AccountID:     accountID,
+		ExcludedFrom:  *account.ExcludedFrom,
+		ExcludedUntil: account.ExcludedUntil,
+	}}, s.exclusions...)
+	return account, nil
+}
+
+func (s *fakeStorage) ExclusionChanges(_ context.Context, accountID int) ([]ExclusionChange, error) {
+	var changes []ExclusionChange
+	for _, c := range s.exclusions {
+		if c.AccountID == accountID {
+			changes = append(changes, c)
+		}
+	}
+	return changes, nil
+}
+
+func (s *fakeStorage) Payments(context.Context, PaymentFilter) ([]Payment, error) {
+	return nil, nil
+}
+
+func (s *fakeStorage) Payment(_ context.Context, transactionID string) (Payment, error) {
+	payment, ok := s.payments[transactionID]
+	if !ok {
+		return Payment{}, ErrPaymentNotFound
+	}
+	return payment, nil
+}
+
+func (s *fakeStorage) CancelPayment(_ context.Context, transactionID string, opts CancelOptions) (Payment, error) {
+	s.lastOpts = opts
+	if s.onCancel != nil {
+		s.onCancel()
+	}
+	payment, ok := s.payments[transactionID]
+	if !ok {
+		return Payment{}, ErrPaymentNotFound
+	}
+	if s.locked[transactionID] {
+		if opts.SkipLocked {
+			return Payment{}, ErrPaymentLocked
+		}
+		return Payment{}, errors.New("lock timeout")
+	}
+	if err := s.cancelErrs[transactionID]; err != nil {
+		return Payment{}, err
+	}
+	if err := payment.Transition(PaymentCancelled, ""); err != nil {
+		return Payment{}, err
+	}
+	s.payments[transactionID] = payment
+	return payment, nil
+}
+
+func (s *fakeStorage) CancellationCandidates(_ context.Context, selection Selection) ([]Payment, error) {
+	s.lastSelected = selection
+	if s.selectErr != nil {
+		return nil, s.selectErr
+	}
+	if len(s.candidates) > selection.Limit {
+		return s.candidates[:selection.Limit], nil
+	}
+	return s.candidates, nil
+}
+
+func (s *fakeStorage) PreviewCancellations(
+	_ context.Context, candidates []Payment, opts CancelOptions,
+) (CancelReport, error) {
+	s.lastOpts = opts
+	var report CancelReport
+	for _, candidate := range candidates {
+		payment := s.payments[candidate.TransactionID]
+		err := s.cancelErrs[candidate.TransactionID]
+		if err == nil {
+			err = payment.Transition(PaymentCancelled, "")
+		}
+		report.Results = append(report.Results, CancelResult{Payment: payment, Err: err})
+		if err == nil {
+			report.Balances = append(report.Balances, Balance{AccountID: payment.AccountID, Currency: payment.Currency})
+		}
+	}
+	return report, nil
+}
+
+func (s *fakeStorage) ParkedCancellations(_ context.Context, limit int) ([]Payment, error) {
+	if len(s.parked) > limit {
+		return s.parked[:limit], nil
+	}
+	return s.parked, nil
+}
+
+func (s *fakeStorage) Balances(context.Context) ([]Balance, error) {
+	return nil, nil
+}
+
+func (s *fakeStorage) CreateAccount(_ context.Context, account Account) (Account, error) {
+	return account, nil
+}
+
+func (s *fakeStorage) Account(_ context.Context, id int) (Account, error) {
+	account, ok := s.accounts[id]
+	if !ok {
+		return Account{}, ErrAccountNotFound
+	}
+	return account, nil
+}
+
+func (s *fakeStorage) SetAccountStatus(_ context.Context, id int, status AccountStatus) (Account, error) {
+	account, ok := s.accounts[id]
+	if !ok {
+		return Account{}, ErrAccountNotFound
+	}
+	if !account.Status.CanTransition(status) {
+		return Account{}, ErrAccountClosed
+	}
+	account.Status = status
+	s.accounts[id] = account
+	return account, nil
+}
+
+func (s *fakeStorage) Accounts(context.Context, Page) ([]Account, int, error) {
+	return nil, 0, nil
+}
+
+func (s *fakeStorage) LedgerMismatches(context.Context) ([]BalanceMismatch, error) {
+	return nil, nil
+}
+
+func newTestService(t *testing.T, storage Storage) *Service {
+	service, err := NewService(storage, &Config{DeficitPolicy: string(DeficitDebt), LimitCoolingOff: 24 * time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return service
+}
+
+func acceptedPayment(transactionID string) Payment {
+	return Payment{
+		AccountID:     1,
+		TransactionID: transactionID,
+		State:         "win",
+		Amount:        1000 * moneyFactor,
+		Currency:      "EUR",
+		SourceType:    3,
+		Status:        PaymentAccepted,
+	}
+}
+
+func TestServiceCancelPayment(t *testing.T) {
+	storage := newFakeStorage(acceptedPayment("accepted"))
+	storage.payments["rejected"] = Payment{TransactionID: "rejected", Status: PaymentRejected}
+	service := newTestService(t, storage)
+
+	testSuite := []struct {
+		testName       string
+		transactionID  string
+		expectedErr    error
+		expectedStatus PaymentStatus
+	}{
+		{
+			testName:       "Cancel accepted payment",
+			transactionID:  "accepted",
+			expectedStatus: PaymentCancelled,
+		},
+		{
+			testName:      "Cancel payment twice",
+			transactionID: "accepted",
+			expectedErr:   ErrPaymentCancelled,
+		},
+		{
+			testName:      "Cancel rejected payment",
+			transactionID: "rejected",
+			expectedErr:   ErrPaymentNotProcessed,
+		},
+		{
+			testName:      "Cancel unknown payment",
+			transactionID: "unknown",
+			expectedErr:   ErrPaymentNotFound,
+		},
+	}
+
+	for _, ts := range testSuite {
+		t.Run(ts.testName, func(t *testing.T) {
+			payment, err := service.CancelPayment(context.Background(), ts.transactionID)
+			if !errors.Is(err, ts.expectedErr) {
+				t.Fatalf("wrong error: expected: %v, actual: %v", ts.expectedErr, err)
+			}
+			if payment.Status != ts.expectedStatus {
+				t.Errorf("wrong status: expected: %q, actual: %q", ts.expectedStatus, payment.Status)
+			}
+		})
+	}
+}
+
+func TestServiceCancelBatch(t *testing.T) {
+	storage := newFakeStorage(acceptedPayment("first"), acceptedPayment("second"), acceptedPayment("third"))
+	storage.cancelErrs["second"] = ErrInsufficientFunds
+	service := newTestService(t, storage)
+
+	selection := Selection{Parity: ParityOdd, Limit: 10}
+	report, err := service.CancelBatch(context.Background(), selection)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if storage.lastSelected.Parity != selection.Parity || storage.lastSelected.Limit != selection.Limit {
+		t.Errorf("wrong selection passed to storage: %+v", storage.lastSelected)
+	}
+	if len(report.Results) != 3 || report.Cancelled() != 2 || report.Failed() != 1 {
+		t.Fatalf("wrong report: %+v", report)
+	}
+
+	failed := report.Results[1]
+	if failed.Payment.TransactionID != "second" || !errors.Is(failed.Err, ErrInsufficientFunds) {
+		t.Errorf("wrong failed result: %+v", failed)
+	}
+	if storage.payments["second"].Status != PaymentAccepted {
+		t.Errorf("failed payment changed status to %q", storage.payments["second"].Status)
+	}
+	for _, transactionID := range []string{"first", "third"} {
+		if storage.payments[transactionID].Status != PaymentCancelled {
+			t.Errorf("payment %s wasn't cancelled", transactionID)
+		}
+	}
+	if storage.lastOpts.DeficitPolicy != DeficitDebt || !storage.lastOpts.SkipLocked {
+		t.Errorf("wrong options passed to storage: %+v", storage.lastOpts)
+	}
+}
+
+func TestServiceCancelBatchSkipsLocked(t *testing.T) {
+	storage := newFakeStorage(acceptedPayment("locked"), acceptedPayment("free"))
+	storage.locked["locked"] = true
+	service := newTestService(t, storage)
+
+	report, err := service.CancelBatch(context.Background(), Selection{Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if report.Cancelled() != 1 || report.Skipped() != 1 || report.Failed() != 0 {
+		t.Errorf("wrong report: cancelled: %d, skipped: %d, failed: %d",
+			report.Cancelled(), report.Skipped(), report.Failed())
+	}
+	if storage.payments["locked"].Status != PaymentAccepted {
+		t.Errorf("locked payment changed status to %q", storage.payments["locked"].Status)
+	}
+}
+
+func TestServiceCancelBatchStopsOnDoneContext(t *testing.T) {
+	storage := newFakeStorage(acceptedPayment("first"), acceptedPayment("second"), acceptedPayment("third"))
+	service := newTestService(t, storage)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	storage.onCancel = cancel
+
+	report, err := service.CancelBatch(ctx, Selection{Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(report.Results) != 1 || report.Results[0].Payment.TransactionID != "first" {
+		t.Fatalf("wrong report: %+v", report)
+	}
+	for _, transactionID := range []string{"second", "third"} {
+		if storage.payments[transactionID].Status != PaymentAccepted {
+			t.Errorf("payment %s was touched after context is done", transactionID)
+		}
+	}
+}
+
+func TestServiceCancelBatchRetriesParked(t *testing.T) {
+	parked := acceptedPayment("parked")
+	parked.Status = PaymentCancelFailed
+	storage := newFakeStorage(acceptedPayment("candidate"))
+	storage.payments[parked.TransactionID] = parked
+	storage.parked = []Payment{parked}
+	service := newTestService(t, storage)
+
+	report, err := service.CancelBatch(context.Background(), Selection{Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(report.Results) != 2 || report.Results[0].Payment.TransactionID != "parked" || report.Cancelled() != 2 {
+		t.Fatalf("wrong report: %+v", report)
+	}
+}
+
+func TestServiceCancelBatchLimit(t *testing.T) {
+	testSuite := []struct {
+		testName       string
+		parked         int
+		candidates     int
+		limit          int
+		expectedParked int
+	}{
+		{
+			testName:       "Parked and candidates fit limit",
+			parked:         1,
+			candidates:     2,
+			limit:          5,
+			expectedParked: 1,
+		},
+		{
+			testName:       "Candidates fill rest of limit",
+			parked:         2,
+			candidates:     5,
+			limit:          4,
+			expectedParked: 2,
+		},
+		{
+			testName:       "Parked fill whole limit",
+			parked:         3,
+			candidates:     5,
+			limit:          3,
+			expectedParked: 3,
+		},
+	}
+
+	for _, ts := range testSuite {
+		t.Run(ts.testName, func(t *testing.T) {
+			storage := newFakeStorage()
+			for i := 0; i < ts.candidates; i++ {
+				candidate := acceptedPayment(fmt.Sprintf("candidate-%d", i))
+				storage.payments[candidate.TransactionID] = candidate
+				storage.candidates = append(storage.candidates, candidate)
+			}
+			for i := 0; i < ts.parked; i++ {
+				parked := acceptedPayment(fmt.Sprintf("parked-%d", i))
+				parked.Status = PaymentCancelFailed
+				storage.payments[parked.TransactionID] = parked
+				storage.parked = append(storage.parked, parked)
+			}
+			service := newTestService(t, storage)
+
+			report, err := service.CancelBatch(context.Background(), Selection{Limit: ts.limit})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			expected := ts.parked + ts.candidates
+			if expected > ts.limit {
+				expected = ts.limit
+			}
+			if len(report.Results) != expected {
+				t.Fatalf("wrong batch size: expected: %d, actual: %d", expected, len(report.Results))
+			}
+			for i, result := range report.Results {
+				isParked := strings.HasPrefix(result.Payment.TransactionID, "parked")
+				if isParked != (i < ts.expectedParked) {
+					t.Errorf("wrong batch order: %s at %d", result.Payment.TransactionID, i)
+				}
+			}
+		})
+	}
+}
+
+func TestServicePreviewBatch(t *testing.T) {
+	parked := acceptedPayment("parked")
+	parked.Status = PaymentCancelFailed
+	storage := newFakeStorage(acceptedPayment("first"), acceptedPayment("second"))
+	storage.payments[parked.TransactionID] = parked
+	storage.parked = []Payment{parked}
+	storage.cancelErrs["second"] = ErrInsufficientFunds
+	service := newTestService(t, storage)
+
+	report, err := service.PreviewBatch(context.Background(), Selection{Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(report.Results) != 3 || report.Results[0].Payment.TransactionID != "parked" {
+		t.Fatalf("wrong report: %+v", report)
+	}
+	if report.Cancelled() != 2 || report.Failed() != 1 || len(report.Balances) != 2 {
+		t.Errorf("wrong report: %+v", report)
+	}
+	for transactionID, payment := range storage.payments {
+		if payment.Status == PaymentCancelled {
+			t.Errorf("payment %s was cancelled by dry run", transactionID)
+		}
+	}
+	if storage.lastOpts.DeficitPolicy != DeficitDebt || !storage.lastOpts.SkipLocked {
+		t.Errorf("wrong options passed to storage: %+v", storage.lastOpts)
+	}
+}
+
+func TestServiceSourceTypes(t *testing.T) {
+	storage := newFakeStorage()
+	service := newTestService(t, storage)
+	ctx := context.Background()
+
+	if _, err := service.CreateSourceType(ctx, "Casino Live"); !errors.Is(err, ErrInvalidSourceType) {
+		t.Errorf("wrong error of invalid value: expected: %v, actual: %v", ErrInvalidSourceType, err)
+	}
+	if _, err := service.CreateSourceType(ctx, "game"); !errors.Is(err, ErrDuplicateSourceType) {
+		t.Errorf("wrong error of duplicate value: expected: %v, actual: %v", ErrDuplicateSourceType, err)
+	}
+
+	created, err := service.CreateSourceType(ctx, "casino-live")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id, err := service.SourceTypeID(ctx, "casino-live"); err != nil || id != created.ID {
+		t.Errorf("created source type isn't cached: id: %d, err: %v", id, err)
+	}
+
+	if _, err := service.SetSourceTypeEnabled(ctx, created.ID, false); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.SourceTypeID(ctx, "casino-live"); !errors.Is(err, ErrSourceTypeDisabled) {
+		t.Errorf("wrong error of disabled source type: expected: %v, actual: %v", ErrSourceTypeDisabled, err)
+	}
+	if v, err := service.SourceType(ctx, "casino-live"); err != nil || v.Enabled {
+		t.Errorf("wrong disabled source type: %+v, err: %v", v, err)
+	}
+
+	// source type changed by another replica is picked up on refresh
+	storage.sourceTypes = append(storage.sourceTypes, SourceType{ID: 42, Value: "poker", Enabled: true})
+	if _, err := service.SourceTypeID(ctx, "poker"); !errors.Is(err, ErrUnknownSourceType) {
+		t.Errorf("wrong error before refresh: expected: %v, actual: %v", ErrUnknownSourceType, err)
+	}
+	if err := service.RefreshSourceTypes(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if id, err := service.SourceTypeID(ctx, "poker"); err != nil || id != 42 {
+		t.Errorf("refreshed source type isn't cached: id: %d, err: %v", id, err)
+	}
+}
+
+func TestServiceProceedPaymentRules(t *testing.T) {
+	applied := acceptedPayment("applied")
+	storage := newFakeStorage(applied)
+	storage.rules = map[int]SourceRules{
+		3: {AllowedStates: []string{"win"}, MaxAmount: 1000 * moneyFactor, MaxDailyVolume: 1500 * moneyFactor},
+	}
+	storage.volume = 1000 * moneyFactor
+	service := newTestService(t, storage)
+
+	lost := acceptedPayment("lost")
+	lost.State = "lost"
+	tooBig := acceptedPayment("too-big")
+	tooBig.Amount = 1001 * moneyFactor
+	small := acceptedPayment("small")
+	small.Amount = 500 * moneyFactor
+
+	testSuite := []struct {
+		testName       string
+		payment        Payment
+		expectedRule   string
+		expectedReplay bool
+	}{
+		{
+			testName:     "Proceed payment in state not allowed",
+			payment:      lost,
+			expectedRule: RuleAllowedStates,
+		},
+		{
+			testName:     "Proceed payment above max amount",
+			payment:      tooBig,
+			expectedRule: RuleMaxAmount,
+		},
+		{
+			testName:     "Proceed payment above daily volume",
+			payment:      acceptedPayment("over-volume"),
+			expectedRule: RuleMaxDailyVolume,
+		},
+		{
+			testName: "Proceed payment within rules",
+			payment:  small,
+		},
+		{
+			testName:       "Resubmit applied payment over daily volume",
+			payment:        applied,
+			expectedReplay: true,
+		},
+	}
+
+	for _, ts := range testSuite {
+		t.Run(ts.testName, func(t *testing.T) {
+			_, replay, err := service.ProceedPayment(context.Background(), ts.payment)
+
+			var violation *RuleViolationError
+			switch {
+			case ts.expectedRule == "" && err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			case ts.expectedRule != "" && !errors.As(err, &violation):
+				t.Fatalf("expected rule violation, actual: %v", err)
+			case ts.expectedRule != "" && violation.Rule != ts.expectedRule:
+				t.Errorf("wrong rule: expected: %s, actual: %s", ts.expectedRule, violation.Rule)
+			}
+			if replay != ts.expectedReplay {
+				t.Errorf("wrong replay flag: expected: %t, actual: %t", ts.expectedReplay, replay)
+			}
+
+			_, stored := storage.payments[ts.payment.TransactionID]
+			if ts.expectedRule != "" && stored {
+				t.Error("payment violating rules reached storage")
+			}
+		})
+	}
+}
+
+func TestAccountLimitChange(t *testing.T) {
+	now := time.Date(2020, 5, 1, 12, 0, 0, 0, time.UTC)
+	coolingOff := 24 * time.Hour
+	pending := func(amount Money, from time.Time) AccountLimit {
+		return AccountLimit{Amount: 100 * moneyFactor, PendingAmount: &amount, PendingFrom: &from}
+	}
+
+	testSuite := []struct {
+		testName        string
+		limit           AccountLimit
+		amount          Money
+		expectedAmount  Money
+		expectedPending Money
+	}{
+		{
+			testName:       "Set new limit",
+			amount:         100 * moneyFactor,
+			expectedAmount: 100 * moneyFactor,
+		},
+		{
+			testName:       "Lower limit",
+			limit:          AccountLimit{Amount: 100 * moneyFactor},
+			amount:         50 * moneyFactor,
+			expectedAmount: 50 * moneyFactor,
+		},
+		{
+			testName:        "Raise limit",
+			limit:           AccountLimit{Amount: 100 * moneyFactor},
+			amount:          200 * moneyFactor,
+			expectedAmount:  100 * moneyFactor,
+			expectedPending: 200 * moneyFactor,
+		},
+		{
+			testName:        "Remove limit",
+			limit:           AccountLimit{Amount: 100 * moneyFactor},
+			amount:          0,
+			expectedAmount:  100 * moneyFactor,
+			expectedPending: 0,
+		},
+		{
+			testName:       "Lower limit with pending raise",
+			limit:          pending(200*moneyFactor, now.Add(time.Hour)),
+			amount:         50 * moneyFactor,
+			expectedAmount: 50 * moneyFactor,
+		},
+		{
+			testName:        "Raise limit after pending raise took effect",
+			limit:           pending(200*moneyFactor, now.Add(-time.Hour)),
+			amount:          300 * moneyFactor,
+			expectedAmount:  200 * moneyFactor,
+			expectedPending: 300 * moneyFactor,
+		},
+	}
+
+	for _, ts := range testSuite {
+		t.Run(ts.testName, func(t *testing.T) {
+			limit := ts.limit
+			limit.Change(ts.amount, now, coolingOff)
+
+			if limit.Amount != ts.expectedAmount {
+				t.Errorf("wrong amount: expected: %s, actual: %s", ts.expectedAmount, limit.Amount)
+			}
+
+			raised := ts.expectedAmount != ts.amount
+			switch {
+			case !raised && limit.PendingAmount != nil:
+				t.Errorf("unexpected pending amount: %s", *limit.PendingAmount)
+			case raised && (limit.PendingAmount == nil || *limit.PendingAmount != ts.expectedPending):
+				t.Errorf("wrong pending amount: expected: %s, actual: %v", ts.expectedPending, limit.PendingAmount)
+			case raised && !limit.PendingFrom.Equal(now.Add(coolingOff)):
+				t.Errorf("wrong pending from: %v", limit.PendingFrom)
+			}
+			if limit.Effective(now.Add(coolingOff)) != ts.amount {
+				t.Errorf("amount isn't effective after cooling-off: %s", limit.Effective(now.Add(coolingOff)))
+			}
+		})
+	}
+}
+
+func TestAccountLimitsCheck(t *testing.T) {
+	now := time.Date(2020, 5, 1, 12, 0, 0, 0, time.UTC)
+	raise := Money(1000 * moneyFactor)
+	later := now.Add(time.Hour)
+	limits := AccountLimits{
+		{Kind: LimitMaxStake, Amount: 100 * moneyFactor},
+		{Kind: LimitDailyLoss, Amount: 200 * moneyFactor, PendingAmount: &raise, PendingFrom: &later},
+		{Kind: LimitWeeklyLoss, Amount: 500 * moneyFactor},
+		{Kind: LimitMonthlyLoss},
+	}
+	losses := Losses{Daily: 150 * moneyFactor, Weekly: 420 * moneyFactor, Monthly: 5000 * moneyFactor}
+
+	payment := func(state string, amount Money) Payment {
+		return Payment{State: state, Amount: amount * moneyFactor}
+	}
+
+	testSuite := []struct {
+		testName      string
+		payment       Payment
+		now           time.Time
+		expectedLimit LimitKind
+	}{
+		{
+			testName: "Win isn't limited",
+			payment:  payment("win", 5000),
+			now:      now,
+		},
+		{
+			testName:      "Stake above max stake",
+			payment:       payment("lost", 101),
+			now:           now,
+			expectedLimit: LimitMaxStake,
+		},
+		{
+			testName:      "Loss above daily limit",
+			payment:       payment("lost", 60),
+			now:           now,
+			expectedLimit: LimitDailyLoss,
+		},
+		{
+			testName:      "Loss above weekly limit after daily raise",
+			payment:       payment("lost", 90),
+			now:           later,
+			expectedLimit: LimitWeeklyLoss,
+		},
+		{
+			testName: "Loss within limits",
+			payment:  payment("lost", 50),
+			now:      now,
+		},
+	}
+
+	for _, ts := range testSuite {
+		t.Run(ts.testName, func(t *testing.T) {
+			err := limits.Check(ts.payment, losses, ts.now)
+
+			var exceeded *LimitExceededError
+			switch {
+			case ts.expectedLimit == "" && err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			case ts.expectedLimit != "" && !errors.As(err, &exceeded):
+				t.Fatalf("expected exceeded limit, actual: %v", err)
+			case ts.expectedLimit != "" && exceeded.Limit != ts.expectedLimit:
+				t.Errorf("wrong limit: expected: %s, actual: %s", ts.expectedLimit, exceeded.Limit)
+			}
+		})
+	}
+}
+
+func TestServiceSetAccountLimit(t *testing.T) {
+	storage := newFakeStorage()
+	storage.accounts[1] = Account{ID: 1, Status: AccountActive}
+	service := newTestService(t, storage)
+	ctx := context.Background()
+
+	testSuite := []struct {
+		testName    string
+		accountID   int
+		kind        string
+		currency    string
+		expectedErr error
+	}{
+		{
+			testName:    "Set limit of unknown kind",
+			accountID:   1,
+			kind:        "hourly_loss",
+			currency:    "EUR",
+			expectedErr: ErrInvalidLimitKind,
+		},
+		{
+			testName:    "Set limit in unsupported currency",
+			accountID:   1,
+			kind:        string(LimitDailyLoss),
+			currency:    "BTC",
+			expectedErr: ErrUnsupportedCurrency,
+		},
+		{
+			testName:    "Set limit of unknown account",
+			accountID:   2,
+			kind:        string(LimitDailyLoss),
+			currency:    "EUR",
+			expectedErr: ErrAccountNotFound,
+		},
+		{
+			testName:  "Set limit",
+			accountID: 1,
+			kind:      string(LimitDailyLoss),
+			currency:  "EUR",
+		},
+	}
+
+	for _, ts := range testSuite {
+		t.Run(ts.testName, func(t *testing.T) {
+			_, err := service.SetAccountLimit(ctx, ts.accountID, ts.kind, ts.currency, 100*moneyFactor)
+			if !errors.Is(err, ts.expectedErr) {
+				t.Fatalf("wrong error: expected: %v, actual: %v", ts.expectedErr, err)
+			}
+		})
+	}
+
+	if storage.coolingOff != 24*time.Hour {
+		t.Errorf("wrong cooling-off passed to storage: %v", storage.coolingOff)
+	}
+
+	limits, err := service.AccountLimits(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(limits) != 1 || limits[0].Kind != LimitDailyLoss || limits[0].Amount != 100*moneyFactor {
+		t.Errorf("wrong limits: %+v", limits)
+	}
+}
+
+func TestAccountExclude(t *testing.T) {
+	now := time.Date(2020, 5, 1, 12, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+	excluded := func(from time.Time, until *time.Time) Account {
+		return Account{ExcludedFrom: &from, ExcludedUntil: until}
+	}
+	tomorrow := now.Add(day)
+	yesterday := now.Add(-day)
+
+	testSuite := []struct {
+		testName          string
+		account           Account
+		period            time.Duration
+		expectedErr       error
+		expectedPermanent bool
+		expectedUntil     time.Time
+	}{
+		{
+			testName:      "Exclude for period",
+			period:        7 * day,
+			expectedUntil: now.Add(7 * day),
+		},
+		{
+			testName:          "Exclude permanently",
+			expectedPermanent: true,
+		},
+		{
+			testName:      "Extend exclusion",
+			account:       excluded(yesterday, &tomorrow),
+			period:        7 * day,
+			expectedUntil: now.Add(7 * day),
+		},
+		{
+			testName:          "Extend exclusion to permanent",
+			account:           excluded(yesterday, &tomorrow),
+			expectedPermanent: true,
+		},
+		{
+			testName:    "Shorten exclusion",
+			account:     excluded(yesterday, &tomorrow),
+			period:      time.Hour,
+			expectedErr: ErrExclusionActive,
+		},
+		{
+			testName:    "Change permanent exclusion",
+			account:     excluded(yesterday, nil),
+			period:      365 * day,
+			expectedErr: ErrExclusionActive,
+		},
+		{
+			testName:      "Exclude after exclusion expired",
+			account:       excluded(now.Add(-7*day), &yesterday),
+			period:        time.Hour,
+			expectedUntil: now.Add(time.Hour),
+		},
+	}
+
+	for _, ts := range testSuite {
+		t.Run(ts.testName, func(t *testing.T) {
+			account := ts.account
+			err := account.Exclude(now, ts.period)
+			if !errors.Is(err, ts.expectedErr) {
+				t.Fatalf("wrong error: expected: %v, actual: %v", ts.expectedErr, err)
+			}
+			if err != nil {
+				return
+			}
+
+			exclusion := account.Exclusion(now)
+			switch {
+			case !exclusion.Excluded:
+				t.Error("account isn't excluded")
+			case exclusion.Permanent != ts.expectedPermanent:
+				t.Errorf("wrong permanent flag: expected: %t, actual: %t", ts.expectedPermanent, exclusion.Permanent)
+			case !ts.expectedPermanent && !exclusion.Until.Equal(ts.expectedUntil):
+				t.Errorf("wrong until: expected: %v, actual: %v", ts.expectedUntil, exclusion.Until)
+			}
+			if !ts.expectedPermanent && account.Excluded(ts.expectedUntil) {
+				t.Error("account is excluded after exclusion expired")
+			}
+		})
+	}
+}
+
+func TestServiceExcludeAccount(t *testing.T) {
+	storage := newFakeStorage()
+	storage.accounts[1] = Account{ID: 1, Status: AccountActive}
+	service := newTestService(t, storage)
+	ctx := context.Background()
+
+	if _, err := service.ExcludeAccount(ctx, 1, -time.Hour); !errors.Is(err, ErrInvalidExclusion) {
+		t.Errorf("wrong error of negative period: expected: %v, actual: %v", ErrInvalidExclusion, err)
+	}
+	if _, err := service.ExcludeAccount(ctx, 2, time.Hour); !errors.Is(err, ErrAccountNotFound) {
+		t.Errorf("wrong error of unknown account: expected: %v, actual: %v", ErrAccountNotFound, err)
+	}
+
+	exclusion, err := service.ExcludeAccount(ctx, 1, 24*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !exclusion.Excluded || exclusion.Permanent {
+		t.Errorf("wrong exclusion: %+v", exclusion)
+	}
+
+	if _, err := service.ExcludeAccount(ctx, 1, time.Hour); !errors.Is(err, ErrExclusionActive) {
+		t.Errorf("wrong error of shortened exclusion: expected: %v, actual: %v", ErrExclusionActive, err)
+	}
+	if exclusion, err := service.ExcludeAccount(ctx, 1, 0); err != nil || !exclusion.Permanent {
+		t.Errorf("wrong permanent exclusion: %+v, err: %v", exclusion, err)
+	}
+
+	if exclusion, err := service.Exclusion(ctx, 1); err != nil || !exclusion.Permanent {
+		t.Errorf("wrong exclusion state: %+v, err: %v", exclusion, err)
+	}
+
+	history, err := service.ExclusionHistory(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 2 || history[0].ExcludedUntil != nil || history[1].ExcludedUntil == nil {
+		t.Errorf("wrong history: %+v", history)
+	}
+}
+
+func TestAccountCheckPayment(t *testing.T) {
+	now := time.Date(2020, 5, 1, 12, 0, 0, 0, time.UTC)
+	from := now.Add(-time.Hour)
+
+	testSuite := []struct {
+		testName    string
+		account     Account
+		expectedErr error
+	}{
+		{
+			testName: "Active account",
+			account:  Account{Status: AccountActive},
+		},
+		{
+			testName:    "Blocked account",
+			account:     Account{Status: AccountBlocked},
+			expectedErr: ErrAccountInactive,
+		},
+		{
+			testName:    "Closed account",
+			account:     Account{Status: AccountClosed},
+			expectedErr: ErrAccountInactive,
+		},
+		{
+			testName:    "Self-excluded account",
+			account:     Account{Status: AccountActive, ExcludedFrom: &from},
+			expectedErr: ErrAccountExcluded,
+		},
+	}
+
+	for _, ts := range testSuite {
+		t.Run(ts.testName, func(t *testing.T) {
+			if err := ts.account.CheckPayment(now); !errors.Is(err, ts.expectedErr) {
+				t.Errorf("wrong error: expected: %v, actual: %v", ts.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestServiceSetAccountStatus(t *testing.T) {
+	storage := newFakeStorage()
+	storage.accounts[1] = Account{ID: 1, Status: AccountActive}
+	service := newTestService(t, storage)
+
+	testSuite := []struct {
+		testName       string
+		accountID      int
+		status         string
+		expectedErr    error
+		expectedStatus AccountStatus
+	}{
+		{
+			testName:    "Set unknown status",
+			accountID:   1,
+			status:      "frozen",
+			expectedErr: ErrInvalidAccountStatus,
+		},
+		{
+			testName:    "Block unknown account",
+			accountID:   2,
+			status:      "blocked",
+			expectedErr: ErrAccountNotFound,
+		},
+		{
+			testName:       "Block account",
+			accountID:      1,
+			status:         "blocked",
+			expectedStatus: AccountBlocked,
+		},
+		{
+			testName:       "Activate blocked account",
+			accountID:      1,
+			status:         "active",
+			expectedStatus: AccountActive,
+		},
+		{
+			testName:       "Close account",
+			accountID:      1,
+			status:         "closed",
+			expectedStatus: AccountClosed,
+		},
+		{
+			testName:    "Reopen closed account",
+			accountID:   1,
+			status:      "active",
+			expectedErr: ErrAccountClosed,
+		},
+	}
+
+	for _, ts := range testSuite {
+		t.Run(ts.testName, func(t *testing.T) {
+			account, err := service.SetAccountStatus(context.Background(), ts.accountID, ts.status)
+			if !errors.Is(err, ts.expectedErr) {
+				t.Fatalf("wrong error: expected: %v, actual: %v", ts.expectedErr, err)
+			}
+			if account.Status != ts.expectedStatus {
+				t.Errorf("wrong status: expected: %q, actual: %q", ts.expectedStatus, account.Status)
+			}
+		})
+	}
+}
+
+func TestNewServiceDeficitPolicy(t *testing.T) {
+	if _, err := NewService(newFakeStorage(), &Config{DeficitPolicy: "ignore"}); err == nil {
+		t.Error("expected error for unknown deficit policy, got nil")
+	}
+}
+
+func TestServiceCancelBatchSelectionError(t *testing.T) {
+	storage := newFakeStorage()
+	storage.selectErr = errors.New("connection refused")
+	service := newTestService(t, storage)
+
+	if _, err := service.CancelBatch(context.Background(), Selection{Limit: 10}); err == nil {
+		t.Error("expected error, got nil")
+	}
+}
+
+func TestServiceProceedPaymentReplay(t *testing.T) {
+	original := acceptedPayment("replayed")
+	original.ID = 7
+	original.CreatedAt = time.Date(2020, 5, 1, 12, 0, 0, 0, time.UTC)
+	service := newTestService(t, newFakeStorage(original))
+
+	resubmitted := original
+	resubmitted.ID = 0
+	resubmitted.CreatedAt = time.Time{}
+	resubmitted.Status = ""
+	different := resubmitted
+	different.State = "lost"
+	fresh := acceptedPayment("new")
+	fresh.Status = ""
+
+	testSuite := []struct {
+		testName       string
+		payment        Payment
+		expectedReplay bool
+		expectedErr    error
+	}{
+		{
+			testName: "Proceed new payment",
+			payment:  fresh,
+		},
+		{
+			testName:       "Resubmit the same payload",
+			payment:        resubmitted,
+			expectedReplay: true,
+		},
+		{
+			testName:    "Resubmit different payload",
+			payment:     different,
+			expectedErr: ErrDuplicateTransaction,
+		},
+	}
+
+	for _, ts := range testSuite {
+		t.Run(ts.testName, func(t *testing.T) {
+			payment, replay, err := service.ProceedPayment(context.Background(), ts.payment)
+			if !errors.Is(err, ts.expectedErr) {
+				t.Fatalf("wrong error: expected: %v, actual: %v", ts.expectedErr, err)
+			}
+			if replay != ts.expectedReplay {
+				t.Errorf("wrong replay flag: expected: %t, actual: %t", ts.expectedReplay, replay)
+			}
+			if err != nil {
+				return
+			}
+			if payment.ID == 0 || payment.CreatedAt.IsZero() || payment.Status != PaymentAccepted {
+				t.Errorf("recorded payment isn't returned: %+v", payment)
+			}
+			if replay && (payment.ID != original.ID || !payment.CreatedAt.Equal(original.CreatedAt)) {
+				t.Errorf("original payment isn't returned: %+v", payment)
+			}
+		})
+	}
+}
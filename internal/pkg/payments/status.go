@@ -0,0 +1,74 @@
+package payments
+
+import "fmt"
+
+// PaymentStatus is a lifecycle status of a payment.
+type PaymentStatus string
+
+// Payment statuses.
+const (
+	// PaymentAccepted is a payment applied to the balance.
+	PaymentAccepted PaymentStatus = "accepted"
+	// PaymentRejected is a payment which wasn't applied, reason tells why.
+	// It may be retried with the same transaction id.
+	PaymentRejected PaymentStatus = "rejected"
+	// PaymentCancelled is an accepted payment reversed afterwards.
+	PaymentCancelled PaymentStatus = "cancelled"
+	// PaymentCancelFailed is an accepted payment which couldn't be reversed,
+	// reason tells why. It's still applied to the balance.
+	PaymentCancelFailed PaymentStatus = "cancel_failed"
+)
+
+// paymentTransitions lists statuses payment may move to from a status.
+// Empty status is a status of a new payment.
+var paymentTransitions = map[PaymentStatus][]PaymentStatus{
+	"":                  {PaymentAccepted, PaymentRejected},
+	PaymentRejected:     {PaymentAccepted, PaymentRejected},
+	PaymentAccepted:     {PaymentCancelled, PaymentCancelFailed},
+	PaymentCancelFailed: {PaymentCancelled, PaymentCancelFailed},
+}
+
+// Valid reports whether s is a known status.
+func (s PaymentStatus) Valid() bool {
+	switch s {
+	case PaymentAccepted, PaymentRejected, PaymentCancelled, PaymentCancelFailed:
+		return true
+	}
+	return false
+}
+
+// Applied reports whether payment in status s is applied to the balance.
+func (s PaymentStatus) Applied() bool {
+	return s == PaymentAccepted || s == PaymentCancelFailed
+}
+
+// CanTransition reports whether payment may move from s to status to.
+func (s PaymentStatus) CanTransition(to PaymentStatus) bool {
+	for _, status := range paymentTransitions[s] {
+		if status == to {
+			return true
+		}
+	}
+	return false
+}
+
+// Transition moves payment to status to with given reason.
+// Cancellation of cancelled or rejected payment returns ErrPaymentCancelled
+// and ErrPaymentNotProcessed, other forbidden transitions return ErrInvalidTransition.
+func (p *Payment) Transition(to PaymentStatus, reason string) error {
+	if !p.Status.CanTransition(to) {
+		switch p.Status {
+		case PaymentCancelled:
+			return ErrPaymentCancelled
+		case PaymentRejected:
+			return ErrPaymentNotProcessed
+		default:
+			return fmt.Errorf("%w from %q to %q", ErrInvalidTransition, p.Status, to)
+		}
+	}
+
+	p.Status = to
+	p.Reason = reason
+
+	return nil
+}
@@ -0,0 +1,73 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-pg/pg/v9"
+
+	"github.com/dink10/enlabs/internal/pkg/payments"
+)
+
+// recordDebt inserts debt of amount left by cancellation of payment within transaction tx.
+func recordDebt(ctx context.Context, tx *pg.Tx, payment payments.Payment, amount payments.Money) error {
+	debt := payments.Debt{
+		AccountID: payment.AccountID,
+		Currency:  payment.Currency,
+		PaymentID: payment.ID,
+		Amount:    amount,
+		Remaining: amount,
+	}
+	if _, err := tx.ModelContext(ctx, &debt).Insert(); err != nil {
+		return fmt.Errorf("failed to execute insert query: %v", err)
+	}
+
+	return nil
+}
+
+// collectDebts pays off outstanding debts of account in currency, the oldest
+// first, as long as balance allows. It must be called inside transaction holding
+// lock on the balance.
+func collectDebts(ctx context.Context, tx *pg.Tx, accountID int, currency string, balance payments.Money) error {
+	var debts []payments.Debt
+	err := tx.ModelContext(ctx, &debts).
+		Where("account_id=?", accountID).
+		Where("currency=?", currency).
+		Where("remaining>0").
+		Order("id ASC").
+		For("UPDATE").
+		Select()
+	if err != nil {
+		return fmt.Errorf("failed to execute select query: %v", err)
+	}
+
+	for i := range debts {
+		if balance <= 0 {
+			break
+		}
+
+		debt := &debts[i]
+		amount := debt.Remaining
+		if amount > balance {
+			amount = balance
+		}
+
+		cancelled := payments.Payment{ID: debt.PaymentID, AccountID: accountID, Currency: currency}
+		if err := postEntries(ctx, tx, payments.Postings(cancelled, payments.EntryDebtCollection, -amount)); err != nil {
+			return err
+		}
+
+		debt.Remaining -= amount
+		balance -= amount
+
+		query := tx.ModelContext(ctx, debt).WherePK().Set("remaining = ?", debt.Remaining)
+		if debt.Remaining == 0 {
+			query.Set("settled_at = now()")
+		}
+		if _, err := query.Update(); err != nil {
+			return fmt.Errorf("failed to execute update query: %v", err)
+		}
+	}
+
+	return nil
+}
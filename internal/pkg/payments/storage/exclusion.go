@@ -0,0 +1,81 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-pg/pg/v9"
+
+	"github.com/dink10/enlabs/internal/pkg/auth"
+	"github.com/dink10/enlabs/internal/pkg/payments"
+)
+
+// ExcludeAccount self-excludes account for period in DB and records the
+// change with principal from ctx in audit history.
+func (s *PaymentStorage) ExcludeAccount(
+	ctx context.Context, accountID int, period time.Duration,
+) (payments.Account, error) {
+	principal, ok := auth.PrincipalFromContext(ctx)
+	if !ok {
+		return payments.Account{}, errNoPrincipal
+	}
+
+	var account payments.Account
+	err := s.db.RunInTransaction(func(tx *pg.Tx) error {
+		account = payments.Account{ID: accountID}
+		err := tx.ModelContext(ctx, &account).WherePK().For("UPDATE").Select()
+		switch {
+		case err == pg.ErrNoRows:
+			return payments.ErrAccountNotFound
+		case err != nil:
+			return fmt.Errorf("failed to execute select query: %v", err)
+		}
+
+		if err := account.Exclude(time.Now(), period); err != nil {
+			return err
+		}
+
+		_, err = tx.ModelContext(ctx, &account).WherePK().
+			Set("excluded_from = ?", account.ExcludedFrom).
+			Set("excluded_until = ?", account.ExcludedUntil).
+			Set("updated_at = now()").
+			Returning("*").
+			Update()
+		if err != nil {
+			return fmt.Errorf("failed to execute update query: %v", err)
+		}
+
+		change := payments.ExclusionChange{
+			AccountID:      account.ID,
+			ExcludedFrom:   *account.ExcludedFrom,
+			ExcludedUntil:  account.ExcludedUntil,
+			ActorRole:      string(principal.Role),
+			ActorAccountID: principal.AccountID,
+		}
+		if _, err := tx.ModelContext(ctx, &change).Insert(); err != nil {
+			return fmt.Errorf("failed to execute insert query: %v", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return payments.Account{}, err
+	}
+
+	return account, nil
+}
+
+// ExclusionChanges returns audit history of account self-exclusion from DB, the latest first.
+func (s *PaymentStorage) ExclusionChanges(ctx context.Context, accountID int) ([]payments.ExclusionChange, error) {
+	var changes []payments.ExclusionChange
+	err := s.db.ModelContext(ctx, &changes).
+		Where("account_id=?", accountID).
+		Order("id DESC").
+		Select()
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute select query: %v", err)
+	}
+
+	return changes, nil
+}
@@ -0,0 +1,57 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-pg/pg/v9"
+
+	"github.com/dink10/enlabs/internal/pkg/payments"
+)
+
+// postEntries inserts ledger entries and applies their wallet side to
+// account balances projection. It must be called inside transaction holding
+// locks on affected balances.
+func postEntries(ctx context.Context, tx *pg.Tx, entries []payments.LedgerEntry) error {
+	if _, err := tx.ModelContext(ctx, &entries).Insert(); err != nil {
+		return fmt.Errorf("failed to execute insert query: %v", err)
+	}
+
+	for _, entry := range entries {
+		if entry.Ledger != payments.LedgerWallet {
+			continue
+		}
+
+		_, err := tx.ModelContext(ctx, (*payments.Balance)(nil)).
+			Set("balance=balance+?-?", entry.Credit, entry.Debit).
+			Set("updated_at=now()").
+			Where("account_id=?", entry.AccountID).
+			Where("currency=?", entry.Currency).
+			Update()
+		if err != nil {
+			return fmt.Errorf("failed to execute update query: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// LedgerMismatches returns balances which differ from the sum of their wallet ledger entries.
+func (s *PaymentStorage) LedgerMismatches(ctx context.Context) ([]payments.BalanceMismatch, error) {
+	mismatches := make([]payments.BalanceMismatch, 0)
+	_, err := s.db.QueryContext(ctx, &mismatches, `
+		SELECT b.account_id, b.currency, b.balance,
+		       coalesce(sum(e.credit - e.debit), 0) AS ledger_balance
+		FROM account_balances b
+		LEFT JOIN ledger_entries e
+		       ON e.account_id = b.account_id AND e.currency = b.currency AND e.ledger = ?
+		GROUP BY b.account_id, b.currency, b.balance
+		HAVING b.balance <> coalesce(sum(e.credit - e.debit), 0)
+		ORDER BY b.account_id, b.currency
+	`, payments.LedgerWallet)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute select query: %v", err)
+	}
+
+	return mismatches, nil
+}
@@ -0,0 +1,125 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-pg/pg/v9"
+
+	"github.com/dink10/enlabs/internal/pkg/payments"
+)
+
+// AccountLimits returns limits of account from DB.
+func (s *PaymentStorage) AccountLimits(ctx context.Context, accountID int) ([]payments.AccountLimit, error) {
+	var limits []payments.AccountLimit
+	err := s.db.ModelContext(ctx, &limits).
+		Where("account_id=?", accountID).
+		Order("currency ASC", "kind ASC").
+		Select()
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute select query: %v", err)
+	}
+
+	return limits, nil
+}
+
+// SetAccountLimit changes amount of account limit in DB, raise is postponed by
+// coolingOff. Balance of the account in the currency is locked, so the change
+// is serialized with payments checked against the limit.
+func (s *PaymentStorage) SetAccountLimit(
+	ctx context.Context, limit payments.AccountLimit, coolingOff time.Duration,
+) (payments.AccountLimit, error) {
+	err := s.db.RunInTransaction(func(tx *pg.Tx) error {
+		if _, err := lockBalance(ctx, tx, limit.AccountID, limit.Currency); err != nil {
+			return err
+		}
+
+		current := payments.AccountLimit{AccountID: limit.AccountID, Currency: limit.Currency, Kind: limit.Kind}
+		err := tx.ModelContext(ctx, &current).WherePK().For("UPDATE").Select()
+		if err != nil && err != pg.ErrNoRows {
+			return fmt.Errorf("failed to execute select query: %v", err)
+		}
+
+		current.Change(limit.Amount, time.Now(), coolingOff)
+		limit = current
+
+		_, err = tx.ModelContext(ctx, &limit).
+			OnConflict("(account_id, currency, kind) DO UPDATE").
+			Set("amount = EXCLUDED.amount").
+			Set("pending_amount = EXCLUDED.pending_amount").
+			Set("pending_from = EXCLUDED.pending_from").
+			Set("updated_at = now()").
+			Returning("*").
+			Insert()
+		if err != nil {
+			return fmt.Errorf("failed to execute insert query: %v", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return payments.AccountLimit{}, err
+	}
+
+	return limit, nil
+}
+
+// checkLimits returns *payments.LimitExceededError if payment breaks a limit
+// of its account within transaction tx. Balance is locked before losses are
+// summed, so concurrent payments of the account are checked one by one.
+func checkLimits(ctx context.Context, tx *pg.Tx, payment payments.Payment) error {
+	if payment.WalletDelta() >= 0 {
+		return nil
+	}
+
+	var limits payments.AccountLimits
+	err := tx.ModelContext(ctx, &limits).
+		Where("account_id=?", payment.AccountID).
+		Where("currency=?", payment.Currency).
+		Select()
+	if err != nil {
+		return fmt.Errorf("failed to execute select query: %v", err)
+	}
+	if len(limits) == 0 {
+		return nil
+	}
+
+	if _, err := lockBalance(ctx, tx, payment.AccountID, payment.Currency); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	losses, err := accountLosses(ctx, tx, payment, now)
+	if err != nil {
+		return err
+	}
+
+	return limits.Check(payment, losses, now)
+}
+
+// accountLosses returns losses of payment account in payment currency within
+// rolling windows ending at now. Payments count by the time they're applied, so
+// a retry accepted now counts now whenever it was first received. The payment
+// itself isn't included.
+func accountLosses(
+	ctx context.Context, tx *pg.Tx, payment payments.Payment, now time.Time,
+) (payments.Losses, error) {
+	var losses payments.Losses
+	err := tx.ModelContext(ctx, (*payments.Payment)(nil)).
+		ColumnExpr("coalesce(sum(amount) FILTER (WHERE applied_at>?), 0)", now.Add(-payments.DailyWindow)).
+		ColumnExpr("coalesce(sum(amount) FILTER (WHERE applied_at>?), 0)", now.Add(-payments.WeeklyWindow)).
+		ColumnExpr("coalesce(sum(amount), 0)").
+		Where("account_id=?", payment.AccountID).
+		Where("currency=?", payment.Currency).
+		Where("state=?", "lost").
+		Where("status IN (?)", pg.In([]payments.PaymentStatus{payments.PaymentAccepted, payments.PaymentCancelFailed})).
+		Where("applied_at>?", now.Add(-payments.MonthlyWindow)).
+		Where("id<>?", payment.ID).
+		Select(pg.Scan(&losses.Daily, &losses.Weekly, &losses.Monthly))
+	if err != nil {
+		return payments.Losses{}, fmt.Errorf("failed to execute select query: %v", err)
+	}
+
+	return losses, nil
+}
@@ -0,0 +1,115 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-pg/pg/v9"
+
+	"github.com/dink10/enlabs/internal/pkg/payments"
+)
+
+// sourceTypeRule keeps currency independent rules of a source type.
+type sourceTypeRule struct {
+	tableName struct{} `pg:"source_type_rules"` // nolint
+
+	SourceTypeID  int      `pg:",pk"`
+	AllowedStates []string `pg:",array"`
+}
+
+// sourceTypeLimit keeps amount limits of a source type in a currency.
+type sourceTypeLimit struct {
+	tableName struct{} `pg:"source_type_limits"` // nolint
+
+	SourceTypeID   int    `pg:",pk"`
+	Currency       string `pg:",pk"`
+	MinAmount      payments.Money
+	MaxAmount      payments.Money
+	MaxDailyVolume payments.Money
+}
+
+// SourceRules returns rules of source type in currency from DB.
+// Source type without rules has zero rules, which don't limit payments.
+func (s *PaymentStorage) SourceRules(
+	ctx context.Context, sourceTypeID int, currency string,
+) (payments.SourceRules, error) {
+	var rules payments.SourceRules
+
+	var rule sourceTypeRule
+	err := s.db.ModelContext(ctx, &rule).
+		Where("source_type_id=?", sourceTypeID).
+		Select()
+	switch {
+	case err == pg.ErrNoRows:
+	case err != nil:
+		return payments.SourceRules{}, fmt.Errorf("failed to execute select query: %v", err)
+	default:
+		rules.AllowedStates = rule.AllowedStates
+	}
+
+	var limit sourceTypeLimit
+	err = s.db.ModelContext(ctx, &limit).
+		Where("source_type_id=?", sourceTypeID).
+		Where("currency=?", currency).
+		Select()
+	switch {
+	case err == pg.ErrNoRows:
+	case err != nil:
+		return payments.SourceRules{}, fmt.Errorf("failed to execute select query: %v", err)
+	default:
+		rules.MinAmount = limit.MinAmount
+		rules.MaxAmount = limit.MaxAmount
+		rules.MaxDailyVolume = limit.MaxDailyVolume
+	}
+
+	return rules, nil
+}
+
+// checkDailyVolume returns *payments.RuleViolationError if payment makes daily
+// volume of its source type in its currency exceed the limit within transaction tx.
+// Limit is locked before volume is summed, so concurrent payments of the source
+// type in the currency are checked one by one.
+func checkDailyVolume(ctx context.Context, tx *pg.Tx, payment payments.Payment) error {
+	var limit sourceTypeLimit
+	err := tx.ModelContext(ctx, &limit).
+		Where("source_type_id=?", payment.SourceType).
+		Where("currency=?", payment.Currency).
+		Where("max_daily_volume>0").
+		For("UPDATE").
+		Select()
+	switch {
+	case err == pg.ErrNoRows:
+		return nil
+	case err != nil:
+		return fmt.Errorf("failed to execute select query: %v", err)
+	}
+
+	volume, err := dailyVolume(ctx, tx, payment)
+	if err != nil {
+		return err
+	}
+
+	rules := payments.SourceRules{MaxDailyVolume: limit.MaxDailyVolume}
+	return rules.CheckDailyVolume(payment, volume)
+}
+
+// dailyVolume returns sum of amounts of payments of payment source type in
+// payment currency applied to balances since the start of the current day.
+// Payments count by the time they're applied, so a retry accepted today counts
+// today whenever it was first received. The payment itself isn't included.
+func dailyVolume(ctx context.Context, tx *pg.Tx, payment payments.Payment) (payments.Money, error) {
+	var volume payments.Money
+	err := tx.ModelContext(ctx, (*payments.Payment)(nil)).
+		ColumnExpr("coalesce(sum(amount), 0)").
+		Where("source_type=?", payment.SourceType).
+		Where("currency=?", payment.Currency).
+		Where("status IN (?)", pg.In([]payments.PaymentStatus{payments.PaymentAccepted, payments.PaymentCancelFailed})).
+		Where("applied_at>=date_trunc('day', now())").
+		Where("id<>?", payment.ID).
+		Select(pg.Scan(&volume))
+	if err != nil {
+		return 0, fmt.Errorf("failed to execute select query: %v", err)
+	}
+
+	return volume, nil
+}
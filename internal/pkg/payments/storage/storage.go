@@ -2,13 +2,21 @@ package storage
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/go-pg/pg/v9"
 
+	"github.com/dink10/enlabs/internal/pkg/auth"
 	"github.com/dink10/enlabs/internal/pkg/payments"
 )
 
+var errNoPrincipal = fmt.Errorf("no authenticated principal in context")
+
+// SourceTypesChannel is a channel notified by DB on every change of source types.
+const SourceTypesChannel = "source_types_changed"
+
 // NewPaymentStorage returns a new instance of PaymentStorage.
 func NewPaymentStorage(db *pg.DB) *PaymentStorage {
 	return &PaymentStorage{db: db}
@@ -23,65 +31,732 @@ type PaymentStorage struct {
 // SourceTypes returns source types.
 func (s *PaymentStorage) SourceTypes(ctx context.Context) ([]payments.SourceType, error) {
 	var sourceTypes []payments.SourceType
-	if err := s.db.ModelContext(ctx, &sourceTypes).Select(); err != nil {
-		return nil, fmt.Errorf("failed to execute insert query: %v", err)
+	if err := s.db.ModelContext(ctx, &sourceTypes).Order("id ASC").Select(); err != nil {
+		return nil, fmt.Errorf("failed to execute select query: %v", err)
 	}
 
 	return sourceTypes, nil
 }
 
-// ProceedPayment processed payment in DB.
-func (s *PaymentStorage) ProceedPayment(ctx context.Context, payment payments.Payment) error {
+// CreateSourceType inserts source type to DB.
+func (s *PaymentStorage) CreateSourceType(
+	ctx context.Context, sourceType payments.SourceType,
+) (payments.SourceType, error) {
+	res, err := s.db.ModelContext(ctx, &sourceType).
+		OnConflict("(value) DO NOTHING").
+		Insert()
+	switch {
+	case err == pg.ErrNoRows:
+		return payments.SourceType{}, payments.ErrDuplicateSourceType
+	case err != nil:
+		return payments.SourceType{}, fmt.Errorf("failed to execute insert query: %v", err)
+	case res.RowsAffected() == 0:
+		return payments.SourceType{}, payments.ErrDuplicateSourceType
+	}
+
+	return sourceType, nil
+}
+
+// SetSourceTypeEnabled enables or disables source type in DB.
+func (s *PaymentStorage) SetSourceTypeEnabled(
+	ctx context.Context, id int, enabled bool,
+) (payments.SourceType, error) {
+	sourceType := payments.SourceType{ID: id}
+	_, err := s.db.ModelContext(ctx, &sourceType).WherePK().
+		Set("enabled = ?", enabled).
+		Returning("*").
+		Update()
+	switch {
+	case err == pg.ErrNoRows:
+		return payments.SourceType{}, payments.ErrSourceTypeNotFound
+	case err != nil:
+		return payments.SourceType{}, fmt.Errorf("failed to execute update query: %v", err)
+	}
+
+	return sourceType, nil
+}
+
+// Currencies returns supported currencies.
+func (s *PaymentStorage) Currencies(ctx context.Context) ([]payments.Currency, error) {
+	var currencies []payments.Currency
+	if err := s.db.ModelContext(ctx, &currencies).Select(); err != nil {
+		return nil, fmt.Errorf("failed to execute select query: %v", err)
+	}
+
+	return currencies, nil
+}
+
+// ProceedPayment processed payment in DB and returns the recorded payment.
+// Payment with transaction id of a rejected payment is a retry: the same
+// record is accepted or rejected again.
+// Payment of inactive or self-excluded account or exceeding a limit of its
+// account is rejected. Payment exceeding daily volume of its source type is
+// refused with *payments.RuleViolationError and isn't recorded.
+// Every attempt is recorded in payment_attempts.
+func (s *PaymentStorage) ProceedPayment(ctx context.Context, payment payments.Payment) (payments.Payment, error) {
+	if _, ok := auth.PrincipalFromContext(ctx); !ok {
+		return payments.Payment{}, errNoPrincipal
+	}
+
+	var proceeded payments.Payment
 	err := s.db.RunInTransaction(func(tx *pg.Tx) error {
-		_, err := tx.ModelContext(ctx, &payment).Insert()
+		account, err := lockAccount(ctx, tx, payment.AccountID)
 		if err != nil {
 			return err
 		}
 
-		var account payments.Account
-		query := tx.ModelContext(ctx, &account).
-			Where("id=?", ctx.Value("account_id"))
-		switch payment.State {
-		case "win":
-			query.Set("balance=balance+?", payment.Amount)
-		case "lost":
-			query.Where("balance >= ?", payment.Amount)
-			query.Set("balance=balance-?", payment.Amount)
+		proceeded, err = claimPayment(ctx, tx, payment, payments.PaymentAccepted, "")
+		if err != nil {
+			return err
 		}
 
-		query.Returning("id").Returning("balance")
-		if _, err := query.Update(); err != nil {
-			if payment.State == "lost" && err == pg.ErrNoRows {
-				return fmt.Errorf("insufficient funds")
-			}
-			return fmt.Errorf("no such account")
+		if err := checkDailyVolume(ctx, tx, proceeded); err != nil {
+			return err
 		}
 
-		return nil
+		if err := account.CheckPayment(time.Now()); err != nil {
+			return err
+		}
+
+		if err := checkLimits(ctx, tx, proceeded); err != nil {
+			return err
+		}
+
+		delta := proceeded.WalletDelta()
+		balance, err := applyPayment(ctx, tx, proceeded, payments.EntryPayment, delta)
+		if err != nil || delta <= 0 {
+			return err
+		}
+
+		return collectDebts(ctx, tx, proceeded.AccountID, proceeded.Currency, balance)
+	})
+	if err == nil {
+		return proceeded, nil
+	}
+	// payment of unknown account can't be recorded even as rejected
+	if errors.Is(err, payments.ErrDuplicateTransaction) || errors.Is(err, payments.ErrAccountNotFound) {
+		return payments.Payment{}, err
+	}
+	// payment breaking rules of its source type is refused, not rejected
+	var violation *payments.RuleViolationError
+	if errors.As(err, &violation) {
+		return payments.Payment{}, err
+	}
+
+	reason := err.Error()
+	rejectErr := s.db.RunInTransaction(func(tx *pg.Tx) error {
+		_, err := claimPayment(ctx, tx, payment, payments.PaymentRejected, reason)
+		return err
 	})
+	if rejectErr != nil && !errors.Is(rejectErr, payments.ErrDuplicateTransaction) {
+		return payments.Payment{}, rejectErr
+	}
+
+	return payments.Payment{}, err
+}
+
+// claimPayment inserts payment in status with reason, or moves rejected payment
+// with the same transaction id and payload to status, and records the attempt.
+// Accepted payment is stamped with the time it's applied, retry included.
+// Any other payment with the same transaction id is final, so ErrDuplicateTransaction
+// is returned.
+func claimPayment(
+	ctx context.Context, tx *pg.Tx, payment payments.Payment, status payments.PaymentStatus, reason string,
+) (payments.Payment, error) {
+	claimed := payment
+	if err := claimed.Transition(status, reason); err != nil {
+		return payments.Payment{}, err
+	}
+	if status == payments.PaymentAccepted {
+		appliedAt := time.Now()
+		claimed.AppliedAt = &appliedAt
+	}
 
+	res, err := tx.ModelContext(ctx, &claimed).
+		OnConflict("(transaction_id) DO NOTHING").
+		Insert()
 	if err != nil {
-		pgErr, ok := err.(pg.Error)
-		switch {
-		case ok && pgErr.IntegrityViolation():
-			return fmt.Errorf("transaction_id already processed")
-		default:
-			payment.Processed = false
-			if _, err := s.db.ModelContext(ctx, &payment).Insert(); err != nil {
-				return err
+		return payments.Payment{}, fmt.Errorf("failed to execute insert query: %v", err)
+	}
+
+	if res.RowsAffected() == 0 {
+		claimed = payments.Payment{}
+		err := tx.ModelContext(ctx, &claimed).
+			Where("transaction_id=?", payment.TransactionID).
+			For("UPDATE").
+			Select()
+		if err != nil {
+			return payments.Payment{}, fmt.Errorf("failed to execute select query: %v", err)
+		}
+
+		if claimed.Status != payments.PaymentRejected || !claimed.SamePayload(payment) {
+			return payments.Payment{}, payments.ErrDuplicateTransaction
+		}
+
+		if err := claimed.Transition(status, reason); err != nil {
+			return payments.Payment{}, err
+		}
+		if err := updatePaymentStatus(ctx, tx, &claimed); err != nil {
+			return payments.Payment{}, err
+		}
+	}
+
+	attempt := payments.PaymentAttempt{
+		PaymentID: claimed.ID,
+		Status:    claimed.Status,
+		Reason:    claimed.Reason,
+	}
+	if _, err := tx.ModelContext(ctx, &attempt).Insert(); err != nil {
+		return payments.Payment{}, fmt.Errorf("failed to execute insert query: %v", err)
+	}
+
+	return claimed, nil
+}
+
+// Payments returns payments matching filter from DB.
+func (s *PaymentStorage) Payments(ctx context.Context, filter payments.PaymentFilter) ([]payments.Payment, error) {
+	pays := make([]payments.Payment, 0, filter.Limit)
+	query := s.db.ModelContext(ctx, &pays).
+		Where("account_id=?", filter.AccountID).
+		Order("id DESC").
+		Limit(filter.Limit)
+
+	if filter.State != "" {
+		query.Where("state=?", filter.State)
+	}
+	if filter.SourceType != 0 {
+		query.Where("source_type=?", filter.SourceType)
+	}
+	if filter.Status != "" {
+		query.Where("status=?", filter.Status)
+	}
+	if !filter.CreatedFrom.IsZero() {
+		query.Where("created_at>=?", filter.CreatedFrom)
+	}
+	if !filter.CreatedTo.IsZero() {
+		query.Where("created_at<?", filter.CreatedTo)
+	}
+	if filter.After != 0 {
+		query.Where("id<?", filter.After)
+	}
+
+	if err := query.Select(); err != nil {
+		return nil, fmt.Errorf("failed to execute select query: %v", err)
+	}
+
+	return pays, nil
+}
+
+// CancellationCandidates returns accepted payments matching selection from DB,
+// the latest first.
+func (s *PaymentStorage) CancellationCandidates(
+	ctx context.Context, selection payments.Selection,
+) ([]payments.Payment, error) {
+	pays := make([]payments.Payment, 0, selection.Limit)
+	query := s.db.ModelContext(ctx, &pays).
+		Where("status=?", payments.PaymentAccepted).
+		Order("id DESC").
+		Limit(selection.Limit)
+
+	switch selection.Parity {
+	case payments.ParityOdd:
+		query.Where("(id % 2) = 1")
+	case payments.ParityEven:
+		query.Where("(id % 2) = 0")
+	}
+	if selection.SamplePercent > 0 && selection.SamplePercent < 100 {
+		query.Where("random() * 100 < ?", selection.SamplePercent)
+	}
+	if !selection.CreatedFrom.IsZero() {
+		query.Where("created_at>=?", selection.CreatedFrom)
+	}
+	if !selection.CreatedTo.IsZero() {
+		query.Where("created_at<?", selection.CreatedTo)
+	}
+	if len(selection.SourceTypes) > 0 {
+		query.Where("source_type IN (SELECT id FROM source_types WHERE value IN (?))", pg.In(selection.SourceTypes))
+	}
+	if len(selection.States) > 0 {
+		query.WhereIn("state IN (?)", selection.States)
+	}
+
+	if err := query.Select(); err != nil {
+		return nil, fmt.Errorf("failed to execute select query: %v", err)
+	}
+
+	return pays, nil
+}
+
+// Payment returns payment by transaction id with its attempts from DB.
+func (s *PaymentStorage) Payment(ctx context.Context, transactionID string) (payments.Payment, error) {
+	var payment payments.Payment
+	err := s.db.ModelContext(ctx, &payment).
+		Where("transaction_id=?", transactionID).
+		Select()
+	switch {
+	case err == pg.ErrNoRows:
+		return payments.Payment{}, payments.ErrPaymentNotFound
+	case err != nil:
+		return payments.Payment{}, fmt.Errorf("failed to execute select query: %v", err)
+	}
+
+	err = s.db.ModelContext(ctx, &payment.Attempts).
+		Where("payment_id=?", payment.ID).
+		Order("id ASC").
+		Select()
+	if err != nil {
+		return payments.Payment{}, fmt.Errorf("failed to execute select query: %v", err)
+	}
+
+	return payment, nil
+}
+
+// CancelPayment reverses accepted payment in DB. If balance doesn't allow
+// reversal, deficit policy decides the outcome: balance goes negative,
+// uncovered amount is recorded as a debt, or payment is parked in cancel_failed status.
+func (s *PaymentStorage) CancelPayment(
+	ctx context.Context, transactionID string, opts payments.CancelOptions,
+) (payments.Payment, error) {
+	var (
+		payment   payments.Payment
+		cancelErr error
+	)
+	err := s.db.RunInTransaction(func(tx *pg.Tx) error {
+		payment, cancelErr = cancelPayment(ctx, tx, transactionID, opts)
+		if !errors.Is(cancelErr, payments.ErrInsufficientFunds) && !errors.Is(cancelErr, payments.ErrCurrencyMismatch) {
+			return cancelErr
+		}
+
+		// payment is parked while it's still locked, so concurrent
+		// cancellation can't be overwritten
+		return failCancellation(ctx, tx, &payment, cancelErr)
+	})
+	if err != nil {
+		return payments.Payment{}, err
+	}
+	if cancelErr != nil {
+		return payments.Payment{}, cancelErr
+	}
+
+	return payment, nil
+}
+
+// errDryRun rolls back transaction of a dry run.
+var errDryRun = errors.New("dry run")
+
+// PreviewCancellations reverses payments like CancelPayment within a single
+// transaction which is rolled back. Outcome of every payment and balances
+// the cancellations would leave are returned, nothing is committed. Payments
+// which can't be reversed aren't parked.
+func (s *PaymentStorage) PreviewCancellations(
+	ctx context.Context, candidates []payments.Payment, opts payments.CancelOptions,
+) (payments.CancelReport, error) {
+	var report payments.CancelReport
+	err := s.db.RunInTransaction(func(tx *pg.Tx) error {
+		report = payments.CancelReport{Results: make([]payments.CancelResult, 0, len(candidates))}
+		for _, candidate := range candidates {
+			payment, err := previewCancellation(ctx, tx, candidate.TransactionID, opts)
+			if err != nil {
+				payment = candidate
 			}
+			report.Results = append(report.Results, payments.CancelResult{Payment: payment, Err: err})
+		}
+
+		balances, err := affectedBalances(ctx, tx, report)
+		if err != nil {
+			return err
+		}
+		report.Balances = balances
+
+		return errDryRun
+	})
+	if err != errDryRun {
+		return payments.CancelReport{}, err
+	}
+
+	return report, nil
+}
+
+// previewCancellation reverses payment within savepoint of transaction tx,
+// so failed reversal doesn't affect the rest of the transaction.
+func previewCancellation(
+	ctx context.Context, tx *pg.Tx, transactionID string, opts payments.CancelOptions,
+) (payments.Payment, error) {
+	if _, err := tx.ExecContext(ctx, "SAVEPOINT cancellation"); err != nil {
+		return payments.Payment{}, fmt.Errorf("failed to execute savepoint query: %v", err)
+	}
+
+	payment, err := cancelPayment(ctx, tx, transactionID, opts)
+	if err != nil {
+		if _, err := tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT cancellation"); err != nil {
+			return payments.Payment{}, fmt.Errorf("failed to execute rollback query: %v", err)
+		}
+		return payments.Payment{}, err
+	}
+
+	return payment, nil
+}
+
+// affectedBalances returns balances changed by cancelled payments of report
+// within transaction tx.
+func affectedBalances(
+	ctx context.Context, tx *pg.Tx, report payments.CancelReport,
+) ([]payments.Balance, error) {
+	type balanceKey struct {
+		accountID int
+		currency  string
+	}
+
+	var accountIDs []int
+	affected := make(map[balanceKey]bool)
+	for _, result := range report.Results {
+		if result.Err != nil {
+			continue
+		}
+		key := balanceKey{accountID: result.Payment.AccountID, currency: result.Payment.Currency}
+		if !affected[key] {
+			affected[key] = true
+			accountIDs = append(accountIDs, key.accountID)
+		}
+	}
+	if len(accountIDs) == 0 {
+		return nil, nil
+	}
+
+	var balances []payments.Balance
+	err := tx.ModelContext(ctx, &balances).
+		WhereIn("account_id IN (?)", accountIDs).
+		Order("account_id ASC", "currency ASC").
+		Select()
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute select query: %v", err)
+	}
+
+	filtered := balances[:0]
+	for _, balance := range balances {
+		if affected[balanceKey{accountID: balance.AccountID, currency: balance.Currency}] {
+			filtered = append(filtered, balance)
+		}
+	}
+
+	return filtered, nil
+}
+
+// cancelPayment reverses accepted payment within transaction tx
+// applying deficit policy of opts.
+func cancelPayment(
+	ctx context.Context, tx *pg.Tx, transactionID string, opts payments.CancelOptions,
+) (payments.Payment, error) {
+	lock := "UPDATE"
+	if opts.SkipLocked {
+		lock = "UPDATE SKIP LOCKED"
+	}
+
+	var payment payments.Payment
+	err := tx.ModelContext(ctx, &payment).
+		Where("transaction_id=?", transactionID).
+		For(lock).
+		Select()
+	switch {
+	case err == pg.ErrNoRows && opts.SkipLocked:
+		// payments are never deleted, so missing row is locked by another transaction
+		return payment, payments.ErrPaymentLocked
+	case err == pg.ErrNoRows:
+		return payment, payments.ErrPaymentNotFound
+	case err != nil:
+		return payment, fmt.Errorf("failed to execute select query: %v", err)
+	}
+
+	if !payment.Status.CanTransition(payments.PaymentCancelled) {
+		return payment, payment.Transition(payments.PaymentCancelled, "")
+	}
+
+	balance, err := lockBalance(ctx, tx, payment.AccountID, payment.Currency)
+	if err != nil {
+		return payment, err
+	}
+
+	deficit, err := opts.DeficitPolicy.Apply(balance.Balance, -payment.WalletDelta())
+	if err != nil {
+		return payment, err
+	}
+
+	if deficit.Debt > 0 {
+		if err := recordDebt(ctx, tx, payment, deficit.Debt); err != nil {
+			return payment, err
+		}
+	}
+
+	if deficit.Delta != 0 {
+		postings := payments.Postings(payment, payments.EntryCancellation, deficit.Delta)
+		if err := postEntries(ctx, tx, postings); err != nil {
+			return payment, err
+		}
+	}
+
+	if err := payment.Transition(payments.PaymentCancelled, deficit.Reason); err != nil {
+		return payment, err
+	}
+
+	return payment, updatePaymentStatus(ctx, tx, &payment)
+}
+
+// ParkedCancellations returns payments in cancel_failed status from DB, the oldest first.
+func (s *PaymentStorage) ParkedCancellations(ctx context.Context, limit int) ([]payments.Payment, error) {
+	pays := make([]payments.Payment, 0, limit)
+	err := s.db.ModelContext(ctx, &pays).
+		Where("status=?", payments.PaymentCancelFailed).
+		Order("id ASC").
+		Limit(limit).
+		Select()
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute select query: %v", err)
+	}
+
+	return pays, nil
+}
+
+// failCancellation moves payment which couldn't be reversed to cancel_failed
+// status within transaction tx holding lock of the payment.
+func failCancellation(ctx context.Context, tx *pg.Tx, payment *payments.Payment, reason error) error {
+	if err := payment.Transition(payments.PaymentCancelFailed, reason.Error()); err != nil {
+		return err
+	}
+
+	return updatePaymentStatus(ctx, tx, payment)
+}
+
+// applyPayment changes balance of payment account by delta and posts entries
+// of kind to the ledger within transaction tx. Balance can't become negative.
+// New balance is returned.
+func applyPayment(
+	ctx context.Context, tx *pg.Tx, payment payments.Payment, kind payments.EntryKind, delta payments.Money,
+) (payments.Money, error) {
+	balance, err := lockBalance(ctx, tx, payment.AccountID, payment.Currency)
+	if err != nil {
+		return 0, err
+	}
+
+	updated, err := balance.Balance.Add(delta)
+	if err != nil {
+		return 0, err
+	}
+	if updated.IsNegative() {
+		return 0, payments.ErrInsufficientFunds
+	}
+
+	if err := postEntries(ctx, tx, payments.Postings(payment, kind, delta)); err != nil {
+		return 0, err
+	}
+
+	return updated, nil
+}
+
+// lockAccount selects account for share within transaction tx, so payment
+// isn't applied concurrently with change of status or exclusion of the account.
+func lockAccount(ctx context.Context, tx *pg.Tx, accountID int) (payments.Account, error) {
+	account := payments.Account{ID: accountID}
+	err := tx.ModelContext(ctx, &account).WherePK().For("SHARE").Select()
+	switch {
+	case err == pg.ErrNoRows:
+		return payments.Account{}, payments.ErrAccountNotFound
+	case err != nil:
+		return payments.Account{}, fmt.Errorf("failed to execute select query: %v", err)
+	}
+
+	return account, nil
+}
+
+// lockBalance selects balance of account in currency for update within transaction tx.
+func lockBalance(ctx context.Context, tx *pg.Tx, accountID int, currency string) (payments.Balance, error) {
+	var balance payments.Balance
+	err := tx.ModelContext(ctx, &balance).
+		Where("account_id=?", accountID).
+		Where("currency=?", currency).
+		For("UPDATE").
+		Select()
+	switch {
+	case err == pg.ErrNoRows:
+		return payments.Balance{}, fmt.Errorf("%w %s", payments.ErrCurrencyMismatch, currency)
+	case err != nil:
+		return payments.Balance{}, fmt.Errorf("failed to execute select query: %v", err)
+	}
+
+	return balance, nil
+}
+
+// updatePaymentStatus saves status and reason of payment within transaction tx.
+// Application time is set when payment is moved to accepted status, cancellation
+// time when it is moved to cancelled status.
+func updatePaymentStatus(ctx context.Context, tx *pg.Tx, payment *payments.Payment) error {
+	query := tx.ModelContext(ctx, payment).WherePK().
+		Set("status = ?", payment.Status).
+		Set("reason = ?", payment.Reason).
+		Returning("*")
+	switch payment.Status {
+	case payments.PaymentAccepted:
+		query.Set("applied_at = now()")
+	case payments.PaymentCancelled:
+		query.Set("cancelled_at = now()")
+	}
+
+	if _, err := query.Update(); err != nil {
+		return fmt.Errorf("failed to execute update query: %v", err)
+	}
+
+	return nil
+}
+
+// Balances returns account balances from DB.
+func (s *PaymentStorage) Balances(ctx context.Context) ([]payments.Balance, error) {
+	principal, ok := auth.PrincipalFromContext(ctx)
+	if !ok {
+		return nil, errNoPrincipal
+	}
+
+	var balances []payments.Balance
+	err := s.db.ModelContext(ctx, &balances).
+		Where("account_id=?", principal.AccountID).
+		Order("currency ASC").
+		Select()
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute select query: %v", err)
+	}
+
+	return balances, nil
+}
+
+// CreateAccount inserts account with its balances into DB.
+func (s *PaymentStorage) CreateAccount(ctx context.Context, account payments.Account) (payments.Account, error) {
+	err := s.db.RunInTransaction(func(tx *pg.Tx) error {
+		if _, err := tx.ModelContext(ctx, &account).Insert(); err != nil {
+			return fmt.Errorf("failed to execute insert query: %v", err)
+		}
+
+		for i := range account.Balances {
+			account.Balances[i].AccountID = account.ID
 		}
+
+		if _, err := tx.ModelContext(ctx, &account.Balances).Insert(); err != nil {
+			return fmt.Errorf("failed to execute insert query: %v", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return payments.Account{}, err
 	}
 
-	return err
+	return account, nil
 }
 
-// Balance returns account balance from DB
-func (s *PaymentStorage) Balance(ctx context.Context) (payments.Account, error) {
+// Account returns account by id from DB.
+func (s *PaymentStorage) Account(ctx context.Context, id int) (payments.Account, error) {
 	var account payments.Account
 	err := s.db.ModelContext(ctx, &account).
-		Where("id=?", ctx.Value("account_id")).
+		Where("id=?", id).
+		Select()
+	switch {
+	case err == pg.ErrNoRows:
+		return payments.Account{}, payments.ErrAccountNotFound
+	case err != nil:
+		return payments.Account{}, fmt.Errorf("failed to execute select query: %v", err)
+	}
+
+	accounts := []payments.Account{account}
+	if err := s.attachBalances(ctx, accounts); err != nil {
+		return payments.Account{}, err
+	}
+
+	return accounts[0], nil
+}
+
+// SetAccountStatus changes status of account in DB.
+func (s *PaymentStorage) SetAccountStatus(
+	ctx context.Context, id int, status payments.AccountStatus,
+) (payments.Account, error) {
+	var account payments.Account
+	err := s.db.RunInTransaction(func(tx *pg.Tx) error {
+		account = payments.Account{ID: id}
+		err := tx.ModelContext(ctx, &account).WherePK().For("UPDATE").Select()
+		switch {
+		case err == pg.ErrNoRows:
+			return payments.ErrAccountNotFound
+		case err != nil:
+			return fmt.Errorf("failed to execute select query: %v", err)
+		}
+
+		if !account.Status.CanTransition(status) {
+			return payments.ErrAccountClosed
+		}
+
+		_, err = tx.ModelContext(ctx, &account).WherePK().
+			Set("status = ?", status).
+			Set("updated_at = now()").
+			Returning("*").
+			Update()
+		if err != nil {
+			return fmt.Errorf("failed to execute update query: %v", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return payments.Account{}, err
+	}
+
+	accounts := []payments.Account{account}
+	if err := s.attachBalances(ctx, accounts); err != nil {
+		return payments.Account{}, err
+	}
+
+	return accounts[0], nil
+}
+
+// Accounts returns a page of accounts from DB and total number of accounts.
+func (s *PaymentStorage) Accounts(ctx context.Context, page payments.Page) ([]payments.Account, int, error) {
+	accounts := make([]payments.Account, 0, page.Limit)
+	total, err := s.db.ModelContext(ctx, &accounts).
+		Order("id ASC").
+		Limit(page.Limit).
+		Offset(page.Offset).
+		SelectAndCount()
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to execute select query: %v", err)
+	}
+
+	if err := s.attachBalances(ctx, accounts); err != nil {
+		return nil, 0, err
+	}
+
+	return accounts, total, nil
+}
+
+func (s *PaymentStorage) attachBalances(ctx context.Context, accounts []payments.Account) error {
+	if len(accounts) == 0 {
+		return nil
+	}
+
+	index := make(map[int]int, len(accounts))
+	ids := make([]int, 0, len(accounts))
+	for i, account := range accounts {
+		index[account.ID] = i
+		ids = append(ids, account.ID)
+	}
+
+	var balances []payments.Balance
+	err := s.db.ModelContext(ctx, &balances).
+		WhereIn("account_id IN (?)", ids).
+		Order("account_id ASC", "currency ASC").
 		Select()
+	if err != nil {
+		return fmt.Errorf("failed to execute select query: %v", err)
+	}
+
+	for _, balance := range balances {
+		i := index[balance.AccountID]
+		accounts[i].Balances = append(accounts[i].Balances, balance)
+	}
 
-	return account, err
+	return nil
 }
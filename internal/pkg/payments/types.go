@@ -1,27 +1,137 @@
 package payments
 
-import "time"
+import (
+	"fmt"
+	"time"
+)
 
-// SourceType is a source type model.
+// SourceType is a source type model. Payments from disabled source types are rejected.
 type SourceType struct {
-	ID    int    `json:"id" pg:",pk"`
-	Value string `json:"value"`
+	ID      int    `json:"id" pg:",pk"`
+	Value   string `json:"value"`
+	Enabled bool   `json:"enabled" pg:",use_zero"`
 }
 
-// Account is a account model.
+// Currency is a supported currency.
+type Currency struct {
+	Code   string `json:"code" pg:",pk"`
+	Places int    `json:"places"`
+}
+
+// AccountStatus is a status of an account.
+type AccountStatus string
+
+// Account statuses. Only active account proceeds payments, accepted payments
+// of blocked and closed accounts still can be cancelled. Closed status is final.
+const (
+	AccountActive  AccountStatus = "active"
+	AccountBlocked AccountStatus = "blocked"
+	AccountClosed  AccountStatus = "closed"
+)
+
+// ParseAccountStatus parses account status, ErrInvalidAccountStatus is returned for unknown statuses.
+func ParseAccountStatus(s string) (AccountStatus, error) {
+	switch status := AccountStatus(s); status {
+	case AccountActive, AccountBlocked, AccountClosed:
+		return status, nil
+	default:
+		return "", fmt.Errorf("%w %q", ErrInvalidAccountStatus, s)
+	}
+}
+
+// CanTransition reports whether account may move from s to status to.
+func (s AccountStatus) CanTransition(to AccountStatus) bool {
+	return s != AccountClosed || to == AccountClosed
+}
+
+// Account is a account model. Account is self-excluded from ExcludedFrom
+// until ExcludedUntil, or permanently if ExcludedUntil isn't set.
 type Account struct {
-	ID      int `pg:",pk"`
-	Balance float64
+	ID            int           `json:"id" pg:",pk"`
+	CreatedAt     time.Time     `json:"createdAt"`
+	UpdatedAt     time.Time     `json:"updatedAt"`
+	Status        AccountStatus `json:"status"`
+	ExcludedFrom  *time.Time    `json:"excludedFrom,omitempty"`
+	ExcludedUntil *time.Time    `json:"excludedUntil,omitempty"`
+	Balances      []Balance     `json:"balances" pg:"-"`
+}
+
+// CheckPayment returns ErrAccountInactive if account isn't active and
+// ErrAccountExcluded if it's self-excluded at now.
+func (a Account) CheckPayment(now time.Time) error {
+	if a.Status != AccountActive {
+		return fmt.Errorf("%w: account is %s", ErrAccountInactive, a.Status)
+	}
+	if a.Excluded(now) {
+		return ErrAccountExcluded
+	}
+
+	return nil
+}
+
+// Balance is a balance of an account in a currency.
+type Balance struct {
+	tableName struct{} `pg:"account_balances"` // nolint
+
+	AccountID int       `json:"-" pg:",pk"`
+	Currency  string    `json:"currency" pg:",pk"`
+	Balance   Money     `json:"balance" swaggertype:"string"`
+	CreatedAt time.Time `json:"-"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// Page is a page of a listing.
+type Page struct {
+	Limit  int
+	Offset int
 }
 
 // Payment is a payment model.
 type Payment struct {
-	ID            int `pg:",pk"`
-	CreatedAt     time.Time
-	AccountID     int
-	TransactionID string
-	State         string
-	Amount        float64
-	SourceType    int
-	Processed     bool
+	ID            int           `json:"id" pg:",pk"`
+	CreatedAt     time.Time     `json:"createdAt"`
+	AccountID     int           `json:"accountId"`
+	TransactionID string        `json:"transactionId"`
+	State         string        `json:"state"`
+	Amount        Money         `json:"amount" swaggertype:"string"`
+	Currency      string        `json:"currency"`
+	SourceType    int           `json:"sourceType"`
+	Status        PaymentStatus `json:"status" swaggertype:"string"`
+	Reason        string        `json:"reason,omitempty"`
+	AppliedAt     *time.Time    `json:"appliedAt,omitempty"`
+	CancelledAt   *time.Time    `json:"cancelledAt,omitempty"`
+
+	Attempts []PaymentAttempt `json:"attempts,omitempty" pg:"-"`
+}
+
+// PaymentAttempt is an attempt to apply a payment.
+type PaymentAttempt struct {
+	ID        int           `json:"id" pg:",pk"`
+	CreatedAt time.Time     `json:"createdAt"`
+	PaymentID int           `json:"-"`
+	Status    PaymentStatus `json:"status" swaggertype:"string"`
+	Reason    string        `json:"reason,omitempty"`
+}
+
+// PaymentFilter narrows payments of an account. Zero fields don't filter.
+// Payments are ordered by id descending, After is id of the last payment
+// of the previous page.
+type PaymentFilter struct {
+	AccountID   int
+	State       string
+	SourceType  int
+	Status      PaymentStatus
+	CreatedFrom time.Time
+	CreatedTo   time.Time
+	After       int
+	Limit       int
+}
+
+// SamePayload reports whether p and other apply the same change to the same balance.
+func (p Payment) SamePayload(other Payment) bool {
+	return p.AccountID == other.AccountID &&
+		p.State == other.State &&
+		p.Amount == other.Amount &&
+		p.Currency == other.Currency &&
+		p.SourceType == other.SourceType
 }
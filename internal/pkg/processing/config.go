@@ -1,6 +1,48 @@
 package processing
 
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// LockName is a name of advisory lock held by instance running post processing.
+const LockName = "processing.cancellation"
+
 // Config keeps configuration of post processing.
+// Schedule is a cron expression with optional seconds field, e.g. "0 0 3 * * MON-FRI",
+// or a descriptor such as "@every 30s", evaluated in Timezone.
+// CancellationTime is kept for compatibility: it's used as "@every N minutes"
+// if Schedule isn't set.
 type Config struct {
-	CancellationTime uint64 `env:"CANCELLATION_TIME,required"`
+	Schedule         string        `env:"CANCELLATION_SCHEDULE"`
+	Timezone         string        `env:"CANCELLATION_TIMEZONE" envDefault:"UTC"`
+	CancellationTime uint64        `env:"CANCELLATION_TIME"`
+	ShutdownTimeout  time.Duration `env:"SHUTDOWN_TIMEOUT" envDefault:"30s"`
+	Policy           PolicyConfig
+}
+
+// Spec returns cron spec of post processing runs.
+func (c *Config) Spec() (string, error) {
+	if c.Schedule != "" {
+		return c.Schedule, nil
+	}
+	if c.CancellationTime == 0 {
+		return "", errors.New("either CANCELLATION_SCHEDULE or CANCELLATION_TIME must be set")
+	}
+
+	return fmt.Sprintf("@every %dm", c.CancellationTime), nil
+}
+
+// PolicyConfig keeps configuration of payments selection for cancellation.
+// Time window is relative to the run: payments created between MaxAge and
+// MinAge ago are selected, zero durations don't limit the window.
+type PolicyConfig struct {
+	Parity        string        `env:"CANCELLATION_PARITY" envDefault:"odd"`
+	SamplePercent int           `env:"CANCELLATION_SAMPLE_PERCENT" envDefault:"100"`
+	MinAge        time.Duration `env:"CANCELLATION_MIN_AGE"`
+	MaxAge        time.Duration `env:"CANCELLATION_MAX_AGE"`
+	SourceTypes   []string      `env:"CANCELLATION_SOURCE_TYPES" envSeparator:","`
+	States        []string      `env:"CANCELLATION_STATES" envSeparator:","`
+	BatchSize     int           `env:"CANCELLATION_BATCH_SIZE" envDefault:"10"`
 }
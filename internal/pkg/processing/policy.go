@@ -0,0 +1,84 @@
+package processing
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/dink10/enlabs/internal/pkg/payments"
+)
+
+// Policy decides which payments are cancelled by a run of post processing.
+type Policy interface {
+	Selection(now time.Time) payments.Selection
+}
+
+// NewPolicy returns Policy configured by cfg.
+func NewPolicy(cfg *PolicyConfig) (Policy, error) {
+	parity := payments.Parity(cfg.Parity)
+	switch parity {
+	case payments.ParityOdd, payments.ParityEven:
+	case "any":
+		parity = payments.ParityAny
+	default:
+		return nil, fmt.Errorf("incorrect parity %q, expected odd, even or any", cfg.Parity)
+	}
+
+	if cfg.SamplePercent < 1 || cfg.SamplePercent > 100 {
+		return nil, fmt.Errorf("incorrect sample percent %d, expected 1..100", cfg.SamplePercent)
+	}
+
+	if cfg.BatchSize < 1 {
+		return nil, fmt.Errorf("incorrect batch size %d", cfg.BatchSize)
+	}
+
+	if cfg.MinAge < 0 || cfg.MaxAge < 0 || (cfg.MaxAge > 0 && cfg.MaxAge <= cfg.MinAge) {
+		return nil, fmt.Errorf("incorrect time window from %s to %s ago", cfg.MaxAge, cfg.MinAge)
+	}
+
+	for _, state := range cfg.States {
+		if state != "win" && state != "lost" {
+			return nil, fmt.Errorf("incorrect state %q, expected win or lost", state)
+		}
+	}
+
+	return &configPolicy{
+		parity:        parity,
+		samplePercent: cfg.SamplePercent,
+		minAge:        cfg.MinAge,
+		maxAge:        cfg.MaxAge,
+		sourceTypes:   cfg.SourceTypes,
+		states:        cfg.States,
+		batchSize:     cfg.BatchSize,
+	}, nil
+}
+
+// configPolicy selects the same filters on every run, only time window moves.
+type configPolicy struct {
+	parity        payments.Parity
+	samplePercent int
+	minAge        time.Duration
+	maxAge        time.Duration
+	sourceTypes   []string
+	states        []string
+	batchSize     int
+}
+
+// Selection implements Policy interface.
+func (p *configPolicy) Selection(now time.Time) payments.Selection {
+	selection := payments.Selection{
+		Parity:        p.parity,
+		SamplePercent: p.samplePercent,
+		SourceTypes:   p.sourceTypes,
+		States:        p.states,
+		Limit:         p.batchSize,
+	}
+
+	if p.maxAge > 0 {
+		selection.CreatedFrom = now.Add(-p.maxAge)
+	}
+	if p.minAge > 0 {
+		selection.CreatedTo = now.Add(-p.minAge)
+	}
+
+	return selection
+}
@@ -0,0 +1,227 @@
+package processing
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/dink10/enlabs/internal/pkg/payments"
+)
+
+func validPolicyConfig() PolicyConfig {
+	return PolicyConfig{Parity: "odd", SamplePercent: 100, BatchSize: 10}
+}
+
+func TestNewPolicy(t *testing.T) {
+	testSuite := []struct {
+		testName    string
+		modify      func(cfg *PolicyConfig)
+		expectedErr bool
+	}{
+		{
+			testName: "Default config",
+			modify:   func(cfg *PolicyConfig) {},
+		},
+		{
+			testName: "Even parity",
+			modify:   func(cfg *PolicyConfig) { cfg.Parity = "even" },
+		},
+		{
+			testName: "Any parity",
+			modify:   func(cfg *PolicyConfig) { cfg.Parity = "any" },
+		},
+		{
+			testName:    "Empty parity",
+			modify:      func(cfg *PolicyConfig) { cfg.Parity = "" },
+			expectedErr: true,
+		},
+		{
+			testName:    "Unknown parity",
+			modify:      func(cfg *PolicyConfig) { cfg.Parity = "prime" },
+			expectedErr: true,
+		},
+		{
+			testName: "Smallest sample percent",
+			modify:   func(cfg *PolicyConfig) { cfg.SamplePercent = 1 },
+		},
+		{
+			testName:    "Zero sample percent",
+			modify:      func(cfg *PolicyConfig) { cfg.SamplePercent = 0 },
+			expectedErr: true,
+		},
+		{
+			testName:    "Sample percent above 100",
+			modify:      func(cfg *PolicyConfig) { cfg.SamplePercent = 101 },
+			expectedErr: true,
+		},
+		{
+			testName: "Smallest batch size",
+			modify:   func(cfg *PolicyConfig) { cfg.BatchSize = 1 },
+		},
+		{
+			testName:    "Zero batch size",
+			modify:      func(cfg *PolicyConfig) { cfg.BatchSize = 0 },
+			expectedErr: true,
+		},
+		{
+			testName:    "Negative batch size",
+			modify:      func(cfg *PolicyConfig) { cfg.BatchSize = -1 },
+			expectedErr: true,
+		},
+		{
+			testName: "Time window",
+			modify: func(cfg *PolicyConfig) {
+				cfg.MinAge = time.Hour
+				cfg.MaxAge = 24 * time.Hour
+			},
+		},
+		{
+			testName: "Min age only",
+			modify:   func(cfg *PolicyConfig) { cfg.MinAge = time.Hour },
+		},
+		{
+			testName: "Max age only",
+			modify:   func(cfg *PolicyConfig) { cfg.MaxAge = time.Hour },
+		},
+		{
+			testName:    "Negative min age",
+			modify:      func(cfg *PolicyConfig) { cfg.MinAge = -time.Hour },
+			expectedErr: true,
+		},
+		{
+			testName:    "Negative max age",
+			modify:      func(cfg *PolicyConfig) { cfg.MaxAge = -time.Hour },
+			expectedErr: true,
+		},
+		{
+			testName: "Empty time window",
+			modify: func(cfg *PolicyConfig) {
+				cfg.MinAge = time.Hour
+				cfg.MaxAge = time.Hour
+			},
+			expectedErr: true,
+		},
+		{
+			testName: "Inverted time window",
+			modify: func(cfg *PolicyConfig) {
+				cfg.MinAge = 2 * time.Hour
+				cfg.MaxAge = time.Hour
+			},
+			expectedErr: true,
+		},
+		{
+			testName: "Known states",
+			modify:   func(cfg *PolicyConfig) { cfg.States = []string{"win", "lost"} },
+		},
+		{
+			testName:    "Unknown state",
+			modify:      func(cfg *PolicyConfig) { cfg.States = []string{"win", "draw"} },
+			expectedErr: true,
+		},
+	}
+
+	for _, ts := range testSuite {
+		t.Run(ts.testName, func(t *testing.T) {
+			cfg := validPolicyConfig()
+			ts.modify(&cfg)
+
+			policy, err := NewPolicy(&cfg)
+			if ts.expectedErr != (err != nil) {
+				t.Fatalf("wrong error: expected error: %t, actual: %v", ts.expectedErr, err)
+			}
+			if err == nil && policy == nil {
+				t.Error("policy is nil")
+			}
+		})
+	}
+}
+
+func TestPolicySelection(t *testing.T) {
+	now := time.Date(2020, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	testSuite := []struct {
+		testName string
+		cfg      PolicyConfig
+		expected payments.Selection
+	}{
+		{
+			testName: "Unlimited time window",
+			cfg:      PolicyConfig{Parity: "odd", SamplePercent: 100, BatchSize: 10},
+			expected: payments.Selection{Parity: payments.ParityOdd, SamplePercent: 100, Limit: 10},
+		},
+		{
+			testName: "Any parity",
+			cfg:      PolicyConfig{Parity: "any", SamplePercent: 50, BatchSize: 5},
+			expected: payments.Selection{Parity: payments.ParityAny, SamplePercent: 50, Limit: 5},
+		},
+		{
+			testName: "Time window",
+			cfg: PolicyConfig{
+				Parity: "even", SamplePercent: 100, BatchSize: 10, MinAge: time.Hour, MaxAge: 24 * time.Hour,
+			},
+			expected: payments.Selection{
+				Parity:        payments.ParityEven,
+				SamplePercent: 100,
+				CreatedFrom:   now.Add(-24 * time.Hour),
+				CreatedTo:     now.Add(-time.Hour),
+				Limit:         10,
+			},
+		},
+		{
+			testName: "Min age only",
+			cfg:      PolicyConfig{Parity: "odd", SamplePercent: 100, BatchSize: 10, MinAge: 30 * time.Minute},
+			expected: payments.Selection{
+				Parity:        payments.ParityOdd,
+				SamplePercent: 100,
+				CreatedTo:     now.Add(-30 * time.Minute),
+				Limit:         10,
+			},
+		},
+		{
+			testName: "Max age only",
+			cfg:      PolicyConfig{Parity: "odd", SamplePercent: 100, BatchSize: 10, MaxAge: 7 * 24 * time.Hour},
+			expected: payments.Selection{
+				Parity:        payments.ParityOdd,
+				SamplePercent: 100,
+				CreatedFrom:   now.Add(-7 * 24 * time.Hour),
+				Limit:         10,
+			},
+		},
+		{
+			testName: "Filters",
+			cfg: PolicyConfig{
+				Parity: "odd", SamplePercent: 100, BatchSize: 10,
+				SourceTypes: []string{"game"}, States: []string{"lost"},
+			},
+			expected: payments.Selection{
+				Parity:        payments.ParityOdd,
+				SamplePercent: 100,
+				SourceTypes:   []string{"game"},
+				States:        []string{"lost"},
+				Limit:         10,
+			},
+		},
+	}
+
+	for _, ts := range testSuite {
+		t.Run(ts.testName, func(t *testing.T) {
+			policy, err := NewPolicy(&ts.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			selection := policy.Selection(now)
+			if !reflect.DeepEqual(selection, ts.expected) {
+				t.Errorf("wrong selection: expected: %+v, actual: %+v", ts.expected, selection)
+			}
+
+			later := policy.Selection(now.Add(time.Hour))
+			if !ts.expected.CreatedFrom.IsZero() && !later.CreatedFrom.Equal(ts.expected.CreatedFrom.Add(time.Hour)) {
+				t.Errorf("time window doesn't move: from %s", later.CreatedFrom)
+			}
+			if !ts.expected.CreatedTo.IsZero() && !later.CreatedTo.Equal(ts.expected.CreatedTo.Add(time.Hour)) {
+				t.Errorf("time window doesn't move: to %s", later.CreatedTo)
+			}
+		})
+	}
+}
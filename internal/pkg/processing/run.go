@@ -0,0 +1,113 @@
+package processing
+
+import (
+	"errors"
+	"time"
+
+	"github.com/dink10/enlabs/internal/pkg/payments"
+)
+
+// RunOutcome is an outcome of a payment in a run.
+type RunOutcome string
+
+// Run outcomes.
+const (
+	OutcomeCancelled RunOutcome = "cancelled"
+	OutcomeSkipped   RunOutcome = "skipped"
+	OutcomeFailed    RunOutcome = "failed"
+)
+
+// Run is a cancellation cycle of post processing. FinishedAt is nil while run is in progress.
+type Run struct {
+	tableName struct{} `pg:"processing_runs"` // nolint
+
+	ID         int        `json:"id" pg:",pk"`
+	StartedAt  time.Time  `json:"startedAt"`
+	FinishedAt *time.Time `json:"finishedAt,omitempty"`
+	Candidates int        `json:"candidates"`
+	Cancelled  int        `json:"cancelled"`
+	Skipped    int        `json:"skipped"`
+	Failed     int        `json:"failed"`
+	Error      string     `json:"error,omitempty"`
+	Items      []RunItem  `json:"items,omitempty" pg:"-"`
+}
+
+// RunItem is an outcome of a payment in a run.
+type RunItem struct {
+	tableName struct{} `pg:"processing_run_items"` // nolint
+
+	ID            int        `json:"-" pg:",pk"`
+	RunID         int        `json:"-"`
+	PaymentID     int        `json:"paymentId"`
+	TransactionID string     `json:"transactionId"`
+	Outcome       RunOutcome `json:"outcome" swaggertype:"string"`
+	Reason        string     `json:"reason,omitempty"`
+}
+
+// Finish fills run with results of report.
+func (r *Run) Finish(report payments.CancelReport) {
+	r.Candidates = len(report.Results)
+	r.Cancelled = report.Cancelled()
+	r.Skipped = report.Skipped()
+	r.Failed = report.Failed()
+
+	r.Items = make([]RunItem, 0, len(report.Results))
+	for _, result := range report.Results {
+		item := RunItem{
+			RunID:         r.ID,
+			PaymentID:     result.Payment.ID,
+			TransactionID: result.Payment.TransactionID,
+			Outcome:       OutcomeCancelled,
+		}
+		switch {
+		case errors.Is(result.Err, payments.ErrPaymentLocked):
+			item.Outcome = OutcomeSkipped
+		case result.Err != nil:
+			item.Outcome = OutcomeFailed
+			item.Reason = result.Err.Error()
+		}
+		r.Items = append(r.Items, item)
+	}
+}
+
+// Preview is an outcome of a dry run: what a run would do to selected payments
+// and balances it would leave. Nothing is committed by a dry run.
+type Preview struct {
+	Candidates int              `json:"candidates"`
+	Cancelled  int              `json:"cancelled"`
+	Skipped    int              `json:"skipped"`
+	Failed     int              `json:"failed"`
+	Items      []RunItem        `json:"items"`
+	Balances   []PreviewBalance `json:"balances"`
+}
+
+// PreviewBalance is a balance of an account left by a dry run.
+type PreviewBalance struct {
+	AccountID int            `json:"accountId"`
+	Currency  string         `json:"currency"`
+	Balance   payments.Money `json:"balance" swaggertype:"string"`
+}
+
+// NewPreview returns preview of report of a dry run.
+func NewPreview(report payments.CancelReport) Preview {
+	var run Run
+	run.Finish(report)
+
+	preview := Preview{
+		Candidates: run.Candidates,
+		Cancelled:  run.Cancelled,
+		Skipped:    run.Skipped,
+		Failed:     run.Failed,
+		Items:      run.Items,
+		Balances:   make([]PreviewBalance, 0, len(report.Balances)),
+	}
+	for _, balance := range report.Balances {
+		preview.Balances = append(preview.Balances, PreviewBalance{
+			AccountID: balance.AccountID,
+			Currency:  balance.Currency,
+			Balance:   balance.Balance,
+		})
+	}
+
+	return preview
+}
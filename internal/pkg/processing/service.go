@@ -0,0 +1,72 @@
+package processing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Processing errors.
+var (
+	ErrRunNotFound   = errors.New("processing run not found")
+	ErrRunInProgress = errors.New("processing run is in progress")
+)
+
+// Storage defines processing service's storage interface.
+type Storage interface {
+	StartRun(context.Context) (Run, error)
+	FinishRun(context.Context, Run) error
+	Runs(context.Context, int) ([]Run, error)
+	Run(context.Context, int) (Run, error)
+}
+
+const (
+	defaultRunsLimit = 20
+	maxRunsLimit     = 100
+)
+
+// Service keeps history of post processing runs.
+type Service struct {
+	storage Storage
+}
+
+// NewService returns a new instance of Service.
+func NewService(storage Storage) *Service {
+	return &Service{storage: storage}
+}
+
+// StartRun records start of a run.
+func (s *Service) StartRun(ctx context.Context) (Run, error) {
+	run, err := s.storage.StartRun(ctx)
+	if err != nil {
+		return Run{}, fmt.Errorf("failed to start run: %w", err)
+	}
+
+	return run, nil
+}
+
+// FinishRun records results of a run with its items.
+func (s *Service) FinishRun(ctx context.Context, run Run) error {
+	if err := s.storage.FinishRun(ctx, run); err != nil {
+		return fmt.Errorf("failed to finish run: %w", err)
+	}
+
+	return nil
+}
+
+// Runs returns recent runs, the latest first.
+func (s *Service) Runs(ctx context.Context, limit int) ([]Run, error) {
+	if limit <= 0 {
+		limit = defaultRunsLimit
+	}
+	if limit > maxRunsLimit {
+		limit = maxRunsLimit
+	}
+
+	return s.storage.Runs(ctx, limit)
+}
+
+// Run returns run by id with its items.
+func (s *Service) Run(ctx context.Context, id int) (Run, error) {
+	return s.storage.Run(ctx, id)
+}
@@ -0,0 +1,100 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-pg/pg/v9"
+
+	"github.com/dink10/enlabs/internal/pkg/processing"
+)
+
+// NewRunStorage returns a new instance of RunStorage.
+func NewRunStorage(db *pg.DB) *RunStorage {
+	return &RunStorage{db: db}
+}
+
+// RunStorage provides access to postgres database and
+// implements processing.Storage interface.
+type RunStorage struct {
+	db *pg.DB
+}
+
+// StartRun inserts a new run into DB.
+func (s *RunStorage) StartRun(ctx context.Context) (processing.Run, error) {
+	var run processing.Run
+	if _, err := s.db.ModelContext(ctx, &run).Insert(); err != nil {
+		return processing.Run{}, fmt.Errorf("failed to execute insert query: %v", err)
+	}
+
+	return run, nil
+}
+
+// FinishRun saves results of run and its items into DB.
+func (s *RunStorage) FinishRun(ctx context.Context, run processing.Run) error {
+	return s.db.RunInTransaction(func(tx *pg.Tx) error {
+		_, err := tx.ModelContext(ctx, &run).WherePK().
+			Set("finished_at = now()").
+			Set("candidates = ?candidates").
+			Set("cancelled = ?cancelled").
+			Set("skipped = ?skipped").
+			Set("failed = ?failed").
+			Set("error = ?error").
+			Update()
+		if err != nil {
+			return fmt.Errorf("failed to execute update query: %v", err)
+		}
+
+		if len(run.Items) == 0 {
+			return nil
+		}
+
+		for i := range run.Items {
+			run.Items[i].RunID = run.ID
+		}
+
+		if _, err := tx.ModelContext(ctx, &run.Items).Insert(); err != nil {
+			return fmt.Errorf("failed to execute insert query: %v", err)
+		}
+
+		return nil
+	})
+}
+
+// Runs returns recent runs from DB, the latest first.
+func (s *RunStorage) Runs(ctx context.Context, limit int) ([]processing.Run, error) {
+	runs := make([]processing.Run, 0, limit)
+	err := s.db.ModelContext(ctx, &runs).
+		Order("id DESC").
+		Limit(limit).
+		Select()
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute select query: %v", err)
+	}
+
+	return runs, nil
+}
+
+// Run returns run by id with its items from DB.
+func (s *RunStorage) Run(ctx context.Context, id int) (processing.Run, error) {
+	var run processing.Run
+	err := s.db.ModelContext(ctx, &run).
+		Where("id=?", id).
+		Select()
+	switch {
+	case err == pg.ErrNoRows:
+		return processing.Run{}, processing.ErrRunNotFound
+	case err != nil:
+		return processing.Run{}, fmt.Errorf("failed to execute select query: %v", err)
+	}
+
+	err = s.db.ModelContext(ctx, &run.Items).
+		Where("run_id=?", run.ID).
+		Order("id ASC").
+		Select()
+	if err != nil {
+		return processing.Run{}, fmt.Errorf("failed to execute select query: %v", err)
+	}
+
+	return run, nil
+}
@@ -13,14 +13,20 @@ import (
 	"github.com/dink10/enlabs/third_party/swagger"
 )
 
+// allowedHeaders are request headers allowed by CORS.
+var allowedHeaders = []string{
+	"Accept", "Authorization", "Gismart-Authorization", "Content-Type",
+	server.HeaderSourceType, server.HeaderSignature, server.HeaderTimestamp, server.HeaderNonce,
+}
+
 // NewDefaultRouter returns router with CORS and request logging middlewares,
 // health-check and swagger documentation end-points.
 func NewDefaultRouter(enableLogging bool) Router {
 	mux := chi.NewRouter()
 
 	corsMiddleware := cors.New(cors.Options{
-		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Gismart-Authorization", "Content-Type"},
+		AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowedHeaders:   allowedHeaders,
 		AllowCredentials: true,
 	}).Handler
 
@@ -1,7 +1,9 @@
 package server
 
 import (
+	"errors"
 	"net/http"
+	"strings"
 
 	"github.com/go-chi/render"
 	"github.com/sirupsen/logrus"
@@ -17,8 +19,20 @@ type Response struct {
 // ErrorResponse is basic error response instance.
 type ErrorResponse struct {
 	*Response
-	Error     error  `json:"-"`
-	ErrorText string `json:"error"`
+	Error     error       `json:"-"`
+	ErrorText string      `json:"error"`
+	Code      string      `json:"code"`
+	Details   interface{} `json:"details,omitempty"`
+}
+
+// Coder is implemented by errors carrying machine-readable code.
+type Coder interface {
+	ErrorCode() string
+}
+
+// Detailer is implemented by errors carrying structured details.
+type Detailer interface {
+	ErrorDetails() interface{}
 }
 
 // NewResponse returns new basic response instance.
@@ -31,14 +45,31 @@ func NewResponse(status int) *Response {
 }
 
 // NewErrorResponse returns new basic error response instance.
+// Code is taken from the error if it implements Coder, otherwise
+// it's derived from the status, e.g. "bad_request". Details are taken
+// from the error if it implements Detailer.
 func NewErrorResponse(status int, err error) *ErrorResponse {
 	r := NewResponse(status)
 	r.Status = false
 
+	code := strings.ToLower(strings.ReplaceAll(http.StatusText(status), " ", "_"))
+	var coder Coder
+	if errors.As(err, &coder) {
+		code = coder.ErrorCode()
+	}
+
+	var details interface{}
+	var detailer Detailer
+	if errors.As(err, &detailer) {
+		details = detailer.ErrorDetails()
+	}
+
 	return &ErrorResponse{
 		Response:  r,
 		Error:     err,
 		ErrorText: err.Error(),
+		Code:      code,
+		Details:   details,
 	}
 }
 
@@ -9,10 +9,14 @@ import (
 )
 
 const (
-	shutdownTimeout   = time.Second * 5
-	HeaderContentType = "Content-Type"
-	HeaderSourceType  = "Source-Type"
-	JsonContentType   = "application/json"
+	shutdownTimeout     = time.Second * 5
+	HeaderContentType   = "Content-Type"
+	HeaderSourceType    = "Source-Type"
+	HeaderSignature     = "Signature"
+	HeaderTimestamp     = "Signature-Timestamp"
+	HeaderNonce         = "Signature-Nonce"
+	HeaderAuthorization = "Authorization"
+	JsonContentType     = "application/json"
 )
 
 // Server is an http.Server wrapper.
@@ -0,0 +1,11 @@
+package signature
+
+import "time"
+
+// Config keeps configuration of request signing.
+// Requests with timestamp differing from server time by more than MaxSkew
+// are rejected. Rotated secret stays valid for RotationOverlap.
+type Config struct {
+	MaxSkew         time.Duration `env:"SIGNATURE_MAX_SKEW" envDefault:"5m"`
+	RotationOverlap time.Duration `env:"SIGNATURE_ROTATION_OVERLAP" envDefault:"24h"`
+}
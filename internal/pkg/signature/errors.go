@@ -0,0 +1,30 @@
+package signature
+
+// Error is a request signing error with a stable machine-readable code.
+type Error struct {
+	Code    string
+	Message string
+}
+
+// Error implements error interface.
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// ErrorCode returns machine-readable code of the error.
+func (e *Error) ErrorCode() string {
+	return e.Code
+}
+
+var (
+	// ErrUnknownSourceType is returned when request names source type which doesn't exist.
+	ErrUnknownSourceType = &Error{Code: "unknown_source_type", Message: "wrong header Source-Type"}
+	// ErrMissingSignature is returned when request lacks signature, timestamp or nonce.
+	ErrMissingSignature = &Error{Code: "missing_signature", Message: "missing signature, timestamp or nonce"}
+	// ErrInvalidSignature is returned when signature doesn't match any valid secret of source type.
+	ErrInvalidSignature = &Error{Code: "invalid_signature", Message: "invalid signature"}
+	// ErrStaleTimestamp is returned when request timestamp is too far from server time.
+	ErrStaleTimestamp = &Error{Code: "stale_timestamp", Message: "request timestamp is out of allowed window"}
+	// ErrReplayedNonce is returned when nonce was already used by source type.
+	ErrReplayedNonce = &Error{Code: "replayed_nonce", Message: "nonce was already used"}
+)
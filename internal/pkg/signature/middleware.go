@@ -0,0 +1,50 @@
+package signature
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/dink10/enlabs/internal/pkg/server"
+)
+
+// maxBodySize limits body of signed requests.
+const maxBodySize = 1 << 20
+
+// Middleware verifies signature of request of source type given in Source-Type
+// header. Stale, replayed or wrongly signed requests are rejected with 401, as
+// well as unsigned requests of source types requiring signing. Requests of
+// unknown source types are rejected with 400.
+func (s *Service) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxBodySize))
+		if err != nil {
+			server.RenderResponse(w, r, server.NewErrorResponse(http.StatusBadRequest, err))
+			return
+		}
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+		err = s.Verify(r.Context(),
+			r.Header.Get(server.HeaderSourceType),
+			r.Header.Get(server.HeaderTimestamp),
+			r.Header.Get(server.HeaderNonce),
+			r.Header.Get(server.HeaderSignature),
+			body,
+		)
+		var signatureErr *Error
+		switch {
+		case errors.Is(err, ErrUnknownSourceType):
+			server.RenderResponse(w, r, server.NewErrorResponse(http.StatusBadRequest, err))
+			return
+		case errors.As(err, &signatureErr):
+			server.RenderResponse(w, r, server.NewErrorResponse(http.StatusUnauthorized, err))
+			return
+		case err != nil:
+			server.RenderResponse(w, r, server.NewErrorResponse(http.StatusInternalServerError, err))
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
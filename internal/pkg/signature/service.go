@@ -0,0 +1,122 @@
+package signature
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// secretSize is a number of random bytes in a generated secret.
+const secretSize = 32
+
+// Storage defines signature service's storage interface.
+type Storage interface {
+	Keyring(context.Context, string) (Keyring, error)
+	RotateSecret(context.Context, Secret, time.Duration) (Secret, error)
+	ClaimNonce(context.Context, string, string) (bool, error)
+	PurgeNonces(context.Context, time.Time) error
+}
+
+// Service verifies signed requests of source types and manages their secrets.
+type Service struct {
+	storage         Storage
+	maxSkew         time.Duration
+	rotationOverlap time.Duration
+	now             func() time.Time
+}
+
+// NewService returns a new instance of Service.
+func NewService(storage Storage, cfg *Config) *Service {
+	return &Service{
+		storage:         storage,
+		maxSkew:         cfg.MaxSkew,
+		rotationOverlap: cfg.RotationOverlap,
+		now:             time.Now,
+	}
+}
+
+// Sign returns hex-encoded HMAC-SHA256 of timestamp, nonce and body, each
+// but the last followed by a newline, keyed by secret.
+func Sign(secret, timestamp, nonce string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	_, _ = mac.Write([]byte(timestamp + "\n" + nonce + "\n"))
+	_, _ = mac.Write(body)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks that request of source type is signed with one of its valid
+// secrets, its unix timestamp is within allowed window and its nonce wasn't
+// used before. Nonce is consumed only by request with valid signature.
+// Unsigned request is accepted only if source type was opted out of signing,
+// signed one is verified anyway.
+func (s *Service) Verify(ctx context.Context, sourceType, timestamp, nonce, signature string, body []byte) error {
+	keyring, err := s.storage.Keyring(ctx, sourceType)
+	if err != nil {
+		return err
+	}
+
+	if !keyring.Required && timestamp == "" && nonce == "" && signature == "" {
+		return nil
+	}
+	if timestamp == "" || nonce == "" || signature == "" {
+		return ErrMissingSignature
+	}
+
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrStaleTimestamp
+	}
+	if skew := s.now().Sub(time.Unix(unix, 0)); skew > s.maxSkew || skew < -s.maxSkew {
+		return ErrStaleTimestamp
+	}
+
+	if !matches(keyring.Secrets, timestamp, nonce, signature, body) {
+		return ErrInvalidSignature
+	}
+
+	claimed, err := s.storage.ClaimNonce(ctx, sourceType, nonce)
+	if err != nil {
+		return err
+	}
+	if !claimed {
+		return ErrReplayedNonce
+	}
+
+	return nil
+}
+
+func matches(secrets []Secret, timestamp, nonce, signature string, body []byte) bool {
+	for _, secret := range secrets {
+		expected := Sign(secret.Secret, timestamp, nonce, body)
+		if hmac.Equal([]byte(expected), []byte(signature)) {
+			return true
+		}
+	}
+	return false
+}
+
+// RotateSecret generates a new secret of source type. Previous secrets stay
+// valid for rotation overlap, so callers have time to switch to the new one.
+// Signing is required of source type from then on.
+func (s *Service) RotateSecret(ctx context.Context, sourceTypeID int) (Secret, error) {
+	raw := make([]byte, secretSize)
+	if _, err := rand.Read(raw); err != nil {
+		return Secret{}, fmt.Errorf("failed to generate secret: %v", err)
+	}
+
+	secret := Secret{SourceTypeID: sourceTypeID, Secret: hex.EncodeToString(raw)}
+
+	return s.storage.RotateSecret(ctx, secret, s.rotationOverlap)
+}
+
+// PurgeNonces deletes nonces which can't be replayed anymore: requests
+// carrying them are rejected by timestamp.
+func (s *Service) PurgeNonces(ctx context.Context) error {
+	return s.storage.PurgeNonces(ctx, s.now().Add(-2*s.maxSkew))
+}
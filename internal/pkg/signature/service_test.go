@@ -0,0 +1,199 @@
+package signature
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// fakeStorage is an in-memory Storage keeping secrets and nonces of "game" source type.
+type fakeStorage struct {
+	secrets  []Secret
+	required bool
+	nonces   map[string]bool
+}
+
+func (s *fakeStorage) Keyring(_ context.Context, sourceType string) (Keyring, error) {
+	if sourceType != "game" {
+		return Keyring{}, ErrUnknownSourceType
+	}
+	return Keyring{Required: s.required, Secrets: s.secrets}, nil
+}
+
+func (s *fakeStorage) RotateSecret(_ context.Context, secret Secret, _ time.Duration) (Secret, error) {
+	s.secrets = append(s.secrets, secret)
+	s.required = true
+	return secret, nil
+}
+
+func (s *fakeStorage) ClaimNonce(_ context.Context, _, nonce string) (bool, error) {
+	if s.nonces[nonce] {
+		return false, nil
+	}
+	s.nonces[nonce] = true
+	return true, nil
+}
+
+func (s *fakeStorage) PurgeNonces(context.Context, time.Time) error {
+	return nil
+}
+
+func TestServiceVerify(t *testing.T) {
+	now := time.Date(2020, 5, 1, 12, 0, 0, 0, time.UTC)
+	storage := &fakeStorage{
+		secrets:  []Secret{{Secret: "current"}, {Secret: "rotated"}},
+		required: true,
+		nonces:   make(map[string]bool),
+	}
+	service := NewService(storage, &Config{MaxSkew: 5 * time.Minute})
+	service.now = func() time.Time { return now }
+
+	body := []byte(`{"state":"win"}`)
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	stale := strconv.FormatInt(now.Add(-10*time.Minute).Unix(), 10)
+
+	testSuite := []struct {
+		testName    string
+		sourceType  string
+		timestamp   string
+		nonce       string
+		signature   string
+		expectedErr error
+	}{
+		{
+			testName:   "Request signed with current secret",
+			sourceType: "game",
+			timestamp:  timestamp,
+			nonce:      "first",
+			signature:  Sign("current", timestamp, "first", body),
+		},
+		{
+			testName:   "Request signed with rotated secret",
+			sourceType: "game",
+			timestamp:  timestamp,
+			nonce:      "second",
+			signature:  Sign("rotated", timestamp, "second", body),
+		},
+		{
+			testName:    "Replayed request",
+			sourceType:  "game",
+			timestamp:   timestamp,
+			nonce:       "first",
+			signature:   Sign("current", timestamp, "first", body),
+			expectedErr: ErrReplayedNonce,
+		},
+		{
+			testName:    "Request with stale timestamp",
+			sourceType:  "game",
+			timestamp:   stale,
+			nonce:       "third",
+			signature:   Sign("current", stale, "third", body),
+			expectedErr: ErrStaleTimestamp,
+		},
+		{
+			testName:    "Request signed with unknown secret",
+			sourceType:  "game",
+			timestamp:   timestamp,
+			nonce:       "fourth",
+			signature:   Sign("guessed", timestamp, "fourth", body),
+			expectedErr: ErrInvalidSignature,
+		},
+		{
+			testName:    "Unsigned request",
+			sourceType:  "game",
+			timestamp:   timestamp,
+			nonce:       "fifth",
+			expectedErr: ErrMissingSignature,
+		},
+		{
+			testName:    "Request of unknown source type",
+			sourceType:  "client",
+			timestamp:   timestamp,
+			nonce:       "sixth",
+			signature:   Sign("current", timestamp, "sixth", body),
+			expectedErr: ErrUnknownSourceType,
+		},
+	}
+
+	for _, ts := range testSuite {
+		t.Run(ts.testName, func(t *testing.T) {
+			err := service.Verify(context.Background(), ts.sourceType, ts.timestamp, ts.nonce, ts.signature, body)
+			if !errors.Is(err, ts.expectedErr) {
+				t.Errorf("wrong error: expected: %v, actual: %v", ts.expectedErr, err)
+			}
+		})
+	}
+
+	if storage.nonces["fourth"] {
+		t.Error("nonce of request with invalid signature was consumed")
+	}
+}
+
+func TestServiceVerifyOptedOut(t *testing.T) {
+	now := time.Date(2020, 5, 1, 12, 0, 0, 0, time.UTC)
+	storage := &fakeStorage{nonces: make(map[string]bool)}
+	service := NewService(storage, &Config{MaxSkew: 5 * time.Minute})
+	service.now = func() time.Time { return now }
+
+	body := []byte(`{"state":"win"}`)
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+
+	testSuite := []struct {
+		testName    string
+		timestamp   string
+		nonce       string
+		signature   string
+		expectedErr error
+	}{
+		{
+			testName: "Unsigned request",
+		},
+		{
+			testName:    "Request signed with unknown secret",
+			timestamp:   timestamp,
+			nonce:       "first",
+			signature:   Sign("guessed", timestamp, "first", body),
+			expectedErr: ErrInvalidSignature,
+		},
+		{
+			testName:    "Request without nonce",
+			timestamp:   timestamp,
+			signature:   Sign("guessed", timestamp, "", body),
+			expectedErr: ErrMissingSignature,
+		},
+	}
+
+	for _, ts := range testSuite {
+		t.Run(ts.testName, func(t *testing.T) {
+			err := service.Verify(context.Background(), "game", ts.timestamp, ts.nonce, ts.signature, body)
+			if !errors.Is(err, ts.expectedErr) {
+				t.Errorf("wrong error: expected: %v, actual: %v", ts.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestServiceRotateSecret(t *testing.T) {
+	storage := &fakeStorage{nonces: make(map[string]bool)}
+	service := NewService(storage, &Config{MaxSkew: 5 * time.Minute, RotationOverlap: time.Hour})
+
+	first, err := service.RotateSecret(context.Background(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := service.RotateSecret(context.Background(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(first.Secret) != 2*secretSize || first.Secret == second.Secret {
+		t.Errorf("wrong generated secrets: %q, %q", first.Secret, second.Secret)
+	}
+
+	err = service.Verify(context.Background(), "game", "", "", "", nil)
+	if !errors.Is(err, ErrMissingSignature) {
+		t.Errorf("unsigned request is accepted after rotation: %v", err)
+	}
+}
@@ -0,0 +1,121 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-pg/pg/v9"
+
+	"github.com/dink10/enlabs/internal/pkg/payments"
+	"github.com/dink10/enlabs/internal/pkg/signature"
+)
+
+// NewSecretStorage returns a new instance of SecretStorage.
+func NewSecretStorage(db *pg.DB) *SecretStorage {
+	return &SecretStorage{db: db}
+}
+
+// SecretStorage provides access to postgres database and
+// implements signature.Storage interface.
+type SecretStorage struct {
+	db *pg.DB
+}
+
+// Keyring returns secrets of source type which aren't expired and whether
+// signing is required of source type from DB.
+// signature.ErrUnknownSourceType is returned for unknown source type.
+func (s *SecretStorage) Keyring(ctx context.Context, sourceType string) (signature.Keyring, error) {
+	var (
+		sourceTypeID int
+		keyring      signature.Keyring
+	)
+	_, err := s.db.QueryOneContext(ctx, pg.Scan(&sourceTypeID, &keyring.Required),
+		`SELECT id, signature_required FROM source_types WHERE value = ?`, sourceType)
+	switch {
+	case err == pg.ErrNoRows:
+		return signature.Keyring{}, signature.ErrUnknownSourceType
+	case err != nil:
+		return signature.Keyring{}, fmt.Errorf("failed to execute select query: %v", err)
+	}
+
+	err = s.db.ModelContext(ctx, &keyring.Secrets).
+		Where("source_type_id=?", sourceTypeID).
+		Where("expires_at IS NULL OR expires_at > now()").
+		Order("id DESC").
+		Select()
+	if err != nil {
+		return signature.Keyring{}, fmt.Errorf("failed to execute select query: %v", err)
+	}
+
+	return keyring, nil
+}
+
+// RotateSecret inserts secret into DB. Secrets of the same source type which
+// don't expire sooner are set to expire after overlap. Signing is required
+// of the source type.
+func (s *SecretStorage) RotateSecret(
+	ctx context.Context, secret signature.Secret, overlap time.Duration,
+) (signature.Secret, error) {
+	err := s.db.RunInTransaction(func(tx *pg.Tx) error {
+		var sourceType payments.SourceType
+		err := tx.ModelContext(ctx, &sourceType).
+			Where("id=?", secret.SourceTypeID).
+			For("UPDATE").
+			Select()
+		switch {
+		case err == pg.ErrNoRows:
+			return payments.ErrSourceTypeNotFound
+		case err != nil:
+			return fmt.Errorf("failed to execute select query: %v", err)
+		}
+
+		interval := fmt.Sprintf("%d milliseconds", overlap.Milliseconds())
+		_, err = tx.ModelContext(ctx, (*signature.Secret)(nil)).
+			Set("expires_at = now() + ?::interval", interval).
+			Where("source_type_id=?", secret.SourceTypeID).
+			Where("expires_at IS NULL OR expires_at > now() + ?::interval", interval).
+			Update()
+		if err != nil {
+			return fmt.Errorf("failed to execute update query: %v", err)
+		}
+
+		if _, err := tx.ModelContext(ctx, &secret).Returning("*").Insert(); err != nil {
+			return fmt.Errorf("failed to execute insert query: %v", err)
+		}
+
+		_, err = tx.ExecContext(ctx, `UPDATE source_types SET signature_required = true WHERE id = ?`,
+			secret.SourceTypeID)
+		if err != nil {
+			return fmt.Errorf("failed to execute update query: %v", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return signature.Secret{}, err
+	}
+
+	return secret, nil
+}
+
+// ClaimNonce records nonce of source type in DB. It reports false if nonce was recorded before.
+func (s *SecretStorage) ClaimNonce(ctx context.Context, sourceType, nonce string) (bool, error) {
+	res, err := s.db.ExecContext(ctx, `INSERT INTO request_nonces (source_type_id, nonce)
+		SELECT id, ? FROM source_types WHERE value = ?
+		ON CONFLICT DO NOTHING`, nonce, sourceType)
+	if err != nil {
+		return false, fmt.Errorf("failed to execute insert query: %v", err)
+	}
+
+	return res.RowsAffected() == 1, nil
+}
+
+// PurgeNonces deletes nonces recorded before given time from DB.
+func (s *SecretStorage) PurgeNonces(ctx context.Context, before time.Time) error {
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM request_nonces WHERE created_at < ?`, before); err != nil {
+		return fmt.Errorf("failed to execute delete query: %v", err)
+	}
+
+	return nil
+}
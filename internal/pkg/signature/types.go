@@ -0,0 +1,23 @@
+package signature
+
+import "time"
+
+// Secret is a shared secret of a source type requests are signed with.
+// Secret without expiration is the current one, rotated secrets expire
+// after overlap window.
+type Secret struct {
+	tableName struct{} `pg:"source_type_secrets"` // nolint
+
+	ID           int        `json:"id" pg:",pk"`
+	CreatedAt    time.Time  `json:"createdAt"`
+	SourceTypeID int        `json:"sourceTypeId"`
+	Secret       string     `json:"secret"`
+	ExpiresAt    *time.Time `json:"expiresAt,omitempty"`
+}
+
+// Keyring keeps valid secrets of a source type. Signing is required of every
+// source type but the ones opted out by migration which have no secret yet.
+type Keyring struct {
+	Required bool
+	Secrets  []Secret
+}
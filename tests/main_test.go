@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"net/url"
+	"os"
 	"strconv"
 	"strings"
 	"sync"
@@ -13,31 +15,45 @@ import (
 	"time"
 
 	"github.com/satori/go.uuid"
+
+	"github.com/dink10/enlabs/internal/pkg/auth"
+	"github.com/dink10/enlabs/internal/pkg/signature"
 )
 
 const (
 	paymentURL = "http://localhost:8085/v1/payments"
 	balanceURL = "http://localhost:8085/v1/payments/balance"
+	accountURL = "http://localhost:8085/v1/accounts"
+	ledgerURL  = "http://localhost:8085/v1/ledger"
+	runsURL    = "http://localhost:8085/v1/processing/runs"
+	sourcesURL = "http://localhost:8085/v1/source-types"
+
+	defaultAuthSecret = "secret"
+	testAccountID     = 1
+	testCurrency      = "EUR"
 )
 
 type payload struct {
 	State         string `json:"state"`
 	Amount        string `json:"amount"`
+	Currency      string `json:"currency"`
 	TransactionID string `json:"transactionId"`
 }
 
-type response struct {
-	Status  bool   `json:"status"`
-	Message string `json:"message"`
+type balancesResponse struct {
+	Status   bool      `json:"status"`
+	Balances []balance `json:"balances"`
 }
 
 func TestRequests(t *testing.T) {
 	client := http.Client{Timeout: time.Duration(10) * time.Second}
+	replayedID := uuid.NewV4().String()
 
 	testSuite := []struct {
 		testName       string
 		state          string
 		amount         string
+		currency       string
 		transactionID  string
 		contentType    string
 		sourceType     string
@@ -52,7 +68,7 @@ func TestRequests(t *testing.T) {
 			contentType:    "plain/text",
 			sourceType:     "payment",
 			expectedStatus: 400,
-			expectedResult: "{\"status\":false,\"error\":\"incorrect Content-Type, required application/json, got plain/text\"}",
+			expectedResult: "{\"status\":false,\"error\":\"incorrect Content-Type, required application/json, got plain/text\",\"code\":\"bad_request\"}",
 		},
 		{
 			testName:       "Test incorrect Source-Type",
@@ -62,7 +78,7 @@ func TestRequests(t *testing.T) {
 			contentType:    "application/json",
 			sourceType:     "client",
 			expectedStatus: 400,
-			expectedResult: "{\"status\":false,\"error\":\"wrong header Source-Type\"}",
+			expectedResult: "{\"status\":false,\"error\":\"wrong header Source-Type\",\"code\":\"unknown_source_type\"}",
 		},
 		{
 			testName:       "Test incorrect amount",
@@ -72,7 +88,7 @@ func TestRequests(t *testing.T) {
 			contentType:    "application/json",
 			sourceType:     "payment",
 			expectedStatus: 400,
-			expectedResult: "{\"status\":false,\"error\":\"incorrect amount value\"}",
+			expectedResult: "{\"status\":false,\"error\":\"incorrect amount value\",\"code\":\"bad_request\"}",
 		},
 		{
 			testName:       "Test negative amount",
@@ -82,7 +98,49 @@ func TestRequests(t *testing.T) {
 			contentType:    "application/json",
 			sourceType:     "payment",
 			expectedStatus: 400,
-			expectedResult: "{\"status\":false,\"error\":\"incorrect amount value\"}",
+			expectedResult: "{\"status\":false,\"error\":\"incorrect amount value\",\"code\":\"bad_request\"}",
+		},
+		{
+			testName:       "Test amount with too many decimal places",
+			state:          "win",
+			amount:         "10.001",
+			transactionID:  uuid.NewV4().String(),
+			contentType:    "application/json",
+			sourceType:     "payment",
+			expectedStatus: 400,
+			expectedResult: "{\"status\":false,\"error\":\"incorrect amount value: too many decimal places: at most 2 allowed\",\"code\":\"bad_request\"}",
+		},
+		{
+			testName:       "Test zero amount",
+			state:          "win",
+			amount:         "0.00",
+			transactionID:  uuid.NewV4().String(),
+			contentType:    "application/json",
+			sourceType:     "payment",
+			expectedStatus: 400,
+			expectedResult: "{\"status\":false,\"error\":\"incorrect amount value\",\"code\":\"bad_request\"}",
+		},
+		{
+			testName:       "Test unsupported currency",
+			state:          "win",
+			amount:         "10",
+			currency:       "XXX",
+			transactionID:  uuid.NewV4().String(),
+			contentType:    "application/json",
+			sourceType:     "payment",
+			expectedStatus: 400,
+			expectedResult: "{\"status\":false,\"error\":\"unsupported currency \\\"XXX\\\"\",\"code\":\"unsupported_currency\"}",
+		},
+		{
+			testName:       "Test currency mismatched with account",
+			state:          "win",
+			amount:         "10",
+			currency:       "USD",
+			transactionID:  uuid.NewV4().String(),
+			contentType:    "application/json",
+			sourceType:     "payment",
+			expectedStatus: 400,
+			expectedResult: "{\"status\":false,\"error\":\"failed to proceed payment: account has no balance in payment currency USD\",\"code\":\"currency_mismatch\"}",
 		},
 		{
 			testName:       "Test incorrect state",
@@ -92,7 +150,7 @@ func TestRequests(t *testing.T) {
 			contentType:    "application/json",
 			sourceType:     "payment",
 			expectedStatus: 400,
-			expectedResult: "{\"status\":false,\"error\":\"State:\\n stateValidator: state field did not pass validation\"}",
+			expectedResult: "{\"status\":false,\"error\":\"State:\\n stateValidator: state field did not pass validation\",\"code\":\"bad_request\"}",
 		},
 		{
 			testName:       "Test incorrect payload",
@@ -108,21 +166,59 @@ func TestRequests(t *testing.T) {
 			testName:       "Test Success transaction",
 			state:          "win",
 			amount:         "10",
-			transactionID:  uuid.NewV4().String(),
+			transactionID:  replayedID,
 			contentType:    "application/json",
 			sourceType:     "payment",
 			expectedStatus: 200,
-			expectedResult: "{\"status\":true,\"message\":\"payment was successfully proceed\"}",
 		},
 		{
 			testName:       "Test Transaction ID idempotence",
 			state:          "win",
 			amount:         "10",
-			transactionID:  "",
+			transactionID:  replayedID,
 			contentType:    "application/json",
 			sourceType:     "payment",
-			expectedStatus: 400,
-			expectedResult: "{\"status\":false,\"error\":\"failed to proceed payment: transaction_id already processed\"}",
+			expectedStatus: 200,
+		},
+		{
+			testName:       "Test Transaction ID resubmitted with different amount",
+			state:          "win",
+			amount:         "11",
+			transactionID:  replayedID,
+			contentType:    "application/json",
+			sourceType:     "payment",
+			expectedStatus: 409,
+			expectedResult: "{\"status\":false,\"error\":\"failed to proceed payment: transaction_id already processed with different payload\",\"code\":\"duplicate_transaction\"}",
+		},
+		{
+			testName:       "Test Transaction ID resubmitted with different state",
+			state:          "lost",
+			amount:         "10",
+			transactionID:  replayedID,
+			contentType:    "application/json",
+			sourceType:     "payment",
+			expectedStatus: 409,
+			expectedResult: "{\"status\":false,\"error\":\"failed to proceed payment: transaction_id already processed with different payload\",\"code\":\"duplicate_transaction\"}",
+		},
+		{
+			testName:       "Test Transaction ID resubmitted from different source",
+			state:          "win",
+			amount:         "10",
+			transactionID:  replayedID,
+			contentType:    "application/json",
+			sourceType:     "game",
+			expectedStatus: 409,
+			expectedResult: "{\"status\":false,\"error\":\"failed to proceed payment: transaction_id already processed with different payload\",\"code\":\"duplicate_transaction\"}",
+		},
+		{
+			testName:       "Test payment above max amount of source type",
+			state:          "win",
+			amount:         "101",
+			transactionID:  uuid.NewV4().String(),
+			contentType:    "application/json",
+			sourceType:     "server",
+			expectedStatus: 422,
+			expectedResult: "{\"status\":false,\"error\":\"failed to proceed payment: payment violates max_amount rule of source type: 101.00, limit 100.00\",\"code\":\"rule_violation\",\"details\":{\"rule\":\"max_amount\",\"limit\":\"100.00\",\"value\":\"101.00\"}}",
 		},
 		{
 			testName:       "Test payment greater than balance",
@@ -131,8 +227,8 @@ func TestRequests(t *testing.T) {
 			transactionID:  uuid.NewV4().String(),
 			contentType:    "application/json",
 			sourceType:     "payment",
-			expectedStatus: 400,
-			expectedResult: "{\"status\":false,\"error\":\"failed to proceed payment: insufficient funds\"}",
+			expectedStatus: 402,
+			expectedResult: "{\"status\":false,\"error\":\"failed to proceed payment: insufficient funds\",\"code\":\"insufficient_funds\"}",
 		},
 	}
 
@@ -141,8 +237,12 @@ func TestRequests(t *testing.T) {
 			payload := payload{
 				State:         ts.state,
 				Amount:        ts.amount,
+				Currency:      ts.currency,
 				TransactionID: ts.transactionID,
 			}
+			if payload.Currency == "" {
+				payload.Currency = testCurrency
+			}
 			if ts.transactionID == "" {
 				payload.TransactionID = testSuite[k-1].transactionID
 			}
@@ -156,6 +256,8 @@ func TestRequests(t *testing.T) {
 			}
 			req.Header.Set("Content-Type", ts.contentType)
 			req.Header.Set("Source-Type", ts.sourceType)
+			req.Header.Set("Authorization", bearer(t))
+			signRequest(req, secretFor(t, ts.sourceType), tpBytes)
 
 			resp, err := client.Do(req)
 			if err != nil {
@@ -184,6 +286,44 @@ func TestRequests(t *testing.T) {
 	}
 }
 
+func TestPaymentReplayResponse(t *testing.T) {
+	client := http.Client{Timeout: time.Duration(10) * time.Second}
+	p := payload{State: "win", Amount: "2.50", Currency: testCurrency, TransactionID: uuid.NewV4().String()}
+
+	type paymentResult struct {
+		Replay  bool `json:"replay"`
+		Payment struct {
+			ID            int       `json:"id"`
+			CreatedAt     time.Time `json:"createdAt"`
+			TransactionID string    `json:"transactionId"`
+			Amount        string    `json:"amount"`
+			Status        string    `json:"status"`
+		} `json:"payment"`
+		Balance struct {
+			Currency string `json:"currency"`
+			Balance  string `json:"balance"`
+		} `json:"balance"`
+	}
+
+	var original, replayed paymentResult
+	if status := sendPayment(t, &client, bearer(t), "payment", p, &original); status != http.StatusOK {
+		t.Fatalf("wrong status code: expected: %d, actual: %d", http.StatusOK, status)
+	}
+	if original.Replay || original.Payment.ID == 0 || original.Payment.Status != "accepted" ||
+		original.Payment.TransactionID != p.TransactionID || original.Balance.Currency != testCurrency {
+		t.Fatalf("wrong response of new payment: %+v", original)
+	}
+
+	if status := sendPayment(t, &client, bearer(t), "payment", p, &replayed); status != http.StatusOK {
+		t.Fatalf("wrong status code: expected: %d, actual: %d", http.StatusOK, status)
+	}
+	if !replayed.Replay || replayed.Payment.ID != original.Payment.ID ||
+		!replayed.Payment.CreatedAt.Equal(original.Payment.CreatedAt) ||
+		replayed.Payment.Amount != original.Payment.Amount || replayed.Payment.Status != original.Payment.Status {
+		t.Errorf("replay doesn't return original payment: original: %+v, replayed: %+v", original, replayed)
+	}
+}
+
 func TestConcurrentRequests(t *testing.T) {
 	client := http.Client{Timeout: time.Duration(10) * time.Second}
 
@@ -263,7 +403,7 @@ func TestConcurrentRequests(t *testing.T) {
 		},
 	}
 
-	balanceBefore, err := getBalance(&client)
+	balanceBefore, err := getBalance(&client, bearer(t))
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -274,6 +414,7 @@ func TestConcurrentRequests(t *testing.T) {
 			payload := payload{
 				State:         ts.state,
 				Amount:        ts.amount,
+				Currency:      testCurrency,
 				TransactionID: u.String(),
 			}
 			tpBytes, err := json.Marshal(payload)
@@ -286,6 +427,8 @@ func TestConcurrentRequests(t *testing.T) {
 			}
 			req.Header.Set("Content-Type", ts.contentType)
 			req.Header.Set("Source-Type", ts.sourceType)
+			req.Header.Set("Authorization", bearer(t))
+			signRequest(req, secretFor(t, ts.sourceType), tpBytes)
 
 			resp, err := client.Do(req)
 			if err != nil {
@@ -313,7 +456,7 @@ func TestConcurrentRequests(t *testing.T) {
 		})
 	}
 
-	balanceAfter, err := getBalance(&client)
+	balanceAfter, err := getBalance(&client, bearer(t))
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -407,11 +550,17 @@ func TestCustomConcurrentRequests(t *testing.T) {
 		},
 	}
 
-	balanceBefore, err := getBalance(&client)
+	authorization := bearer(t)
+	balanceBefore, err := getBalance(&client, authorization)
 	if err != nil {
 		t.Fatal(err)
 	}
 
+	secrets := make(map[string]string)
+	for _, ts := range testSuite {
+		secrets[ts.sourceType] = secretFor(t, ts.sourceType)
+	}
+
 	var wg sync.WaitGroup
 	wg.Add(len(testSuite))
 	for _, ts := range testSuite {
@@ -421,27 +570,34 @@ func TestCustomConcurrentRequests(t *testing.T) {
 			payload := payload{
 				State:         td.state,
 				Amount:        td.amount,
+				Currency:      testCurrency,
 				TransactionID: u.String(),
 			}
 			tpBytes, err := json.Marshal(payload)
 			if err != nil {
-				t.Fatal(err)
+				t.Error(err)
+				return
 			}
 			req, err := http.NewRequest("POST", paymentURL, bytes.NewBuffer(tpBytes))
 			if err != nil {
-				t.Fatal(err)
+				t.Error(err)
+				return
 			}
 			req.Header.Set("Content-Type", td.contentType)
 			req.Header.Set("Source-Type", td.sourceType)
+			req.Header.Set("Authorization", authorization)
+			signRequest(req, secrets[td.sourceType], tpBytes)
 
 			resp, err := client.Do(req)
 			if err != nil {
-				t.Fatal(err)
+				t.Error(err)
+				return
 			}
 
 			body, err := ioutil.ReadAll(resp.Body)
 			if err != nil {
-				t.Fatal(err)
+				t.Error(err)
+				return
 			}
 			defer func() {
 				errB := resp.Body.Close()
@@ -462,7 +618,7 @@ func TestCustomConcurrentRequests(t *testing.T) {
 
 	wg.Wait()
 
-	balanceAfter, err := getBalance(&client)
+	balanceAfter, err := getBalance(&client, authorization)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -475,28 +631,1307 @@ func TestCustomConcurrentRequests(t *testing.T) {
 	}
 }
 
-func getBalance(client *http.Client) (float64, error) {
-	req, err := http.NewRequest("GET", balanceURL, nil)
-	if err != nil {
-		return 0, err
+func TestUnauthorizedRequests(t *testing.T) {
+	client := http.Client{Timeout: time.Duration(10) * time.Second}
+
+	testSuite := []struct {
+		testName      string
+		authorization string
+	}{
+		{testName: "Missing token", authorization: ""},
+		{testName: "Malformed token", authorization: "Bearer token"},
+		{testName: "Token with wrong signature", authorization: "Bearer " + token(t, "wrong-secret", player)},
 	}
 
-	resp, err := client.Do(req)
-	if err != nil {
-		return 0, err
+	for _, ts := range testSuite {
+		t.Run(ts.testName, func(t *testing.T) {
+			req, err := http.NewRequest("GET", balanceURL, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ts.authorization != "" {
+				req.Header.Set("Authorization", ts.authorization)
+			}
+
+			resp, err := client.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer func() {
+				_ = resp.Body.Close()
+			}()
+
+			if resp.StatusCode != http.StatusUnauthorized {
+				t.Errorf("wrong status code: expected: %d, actual: %d", http.StatusUnauthorized, resp.StatusCode)
+			}
+		})
 	}
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return 0, err
+}
+
+func TestAccounts(t *testing.T) {
+	client := http.Client{Timeout: time.Duration(10) * time.Second}
+
+	var created accountResponse
+	body := `{"currencies":["EUR","BTC"]}`
+	status := doJSON(t, &client, "POST", accountURL, adminBearer(t), body, &created)
+	if status != http.StatusCreated {
+		t.Fatalf("wrong status code: expected: %d, actual: %d", http.StatusCreated, status)
+	}
+	if created.Account.ID == 0 || created.Account.Status != "active" || len(created.Account.Balances) != 2 {
+		t.Fatalf("wrong created account: %+v", created.Account)
+	}
+	for _, b := range created.Account.Balances {
+		if b.Balance != "0.00" {
+			t.Fatalf("wrong created account balance: %+v", b)
+		}
 	}
-	defer func() {
-		_ = resp.Body.Close()
-	}()
 
-	var r response
-	if err := json.Unmarshal(body, &r); err != nil {
-		return 0, err
+	status = doJSON(t, &client, "POST", accountURL, adminBearer(t), `{"currencies":["XXX"]}`, nil)
+	if status != http.StatusBadRequest {
+		t.Errorf("wrong status code: expected: %d, actual: %d", http.StatusBadRequest, status)
+	}
+
+	testSuite := []struct {
+		testName       string
+		method         string
+		url            string
+		authorization  string
+		expectedStatus int
+	}{
+		{
+			testName:       "Fetch account by admin",
+			method:         "GET",
+			url:            fmt.Sprintf("%s/%d", accountURL, created.Account.ID),
+			authorization:  adminBearer(t),
+			expectedStatus: http.StatusOK,
+		},
+		{
+			testName:       "Fetch own account",
+			method:         "GET",
+			url:            fmt.Sprintf("%s/%d", accountURL, testAccountID),
+			authorization:  bearer(t),
+			expectedStatus: http.StatusOK,
+		},
+		{
+			testName:       "Fetch foreign account",
+			method:         "GET",
+			url:            fmt.Sprintf("%s/%d", accountURL, created.Account.ID),
+			authorization:  bearer(t),
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			testName:       "Fetch unknown account",
+			method:         "GET",
+			url:            fmt.Sprintf("%s/%d", accountURL, 1<<30),
+			authorization:  adminBearer(t),
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			testName:       "List accounts by admin",
+			method:         "GET",
+			url:            accountURL + "?limit=1&offset=0",
+			authorization:  adminBearer(t),
+			expectedStatus: http.StatusOK,
+		},
+		{
+			testName:       "List accounts by player",
+			method:         "GET",
+			url:            accountURL,
+			authorization:  bearer(t),
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			testName:       "Create account by player",
+			method:         "POST",
+			url:            accountURL,
+			authorization:  bearer(t),
+			expectedStatus: http.StatusForbidden,
+		},
+	}
+
+	for _, ts := range testSuite {
+		t.Run(ts.testName, func(t *testing.T) {
+			status := doJSON(t, &client, ts.method, ts.url, ts.authorization, `{"currencies":["EUR"]}`, nil)
+			if ts.expectedStatus != status {
+				t.Errorf("wrong status code: expected: %d, actual: %d", ts.expectedStatus, status)
+			}
+		})
+	}
+
+	unknown := "Bearer " + token(t, authSecret(), auth.Principal{AccountID: 1 << 30, Role: auth.RolePlayer})
+	p := payload{State: "win", Amount: "1", Currency: testCurrency, TransactionID: uuid.NewV4().String()}
+	if status := postPayment(t, &client, unknown, p); status != http.StatusNotFound {
+		t.Errorf("wrong status code of payment to unknown account: expected: %d, actual: %d",
+			http.StatusNotFound, status)
+	}
+}
+
+func TestAccountStatus(t *testing.T) {
+	client := http.Client{Timeout: time.Duration(10) * time.Second}
+
+	var created accountResponse
+	status := doJSON(t, &client, "POST", accountURL, adminBearer(t), `{"currencies":["EUR"]}`, &created)
+	if status != http.StatusCreated {
+		t.Fatalf("wrong status code: expected: %d, actual: %d", http.StatusCreated, status)
+	}
+	owner := "Bearer " + token(t, authSecret(), auth.Principal{AccountID: created.Account.ID, Role: auth.RolePlayer})
+	updateURL := fmt.Sprintf("%s/%d", accountURL, created.Account.ID)
+
+	testSuite := []struct {
+		testName       string
+		body           string
+		authorization  string
+		expectedStatus int
+		paymentStatus  int
+	}{
+		{
+			testName:       "Block account by player",
+			body:           `{"status": "blocked"}`,
+			authorization:  owner,
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			testName:       "Set unknown status",
+			body:           `{"status": "frozen"}`,
+			authorization:  adminBearer(t),
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			testName:       "Block account",
+			body:           `{"status": "blocked"}`,
+			authorization:  adminBearer(t),
+			expectedStatus: http.StatusOK,
+			paymentStatus:  http.StatusForbidden,
+		},
+		{
+			testName:       "Activate account",
+			body:           `{"status": "active"}`,
+			authorization:  adminBearer(t),
+			expectedStatus: http.StatusOK,
+			paymentStatus:  http.StatusOK,
+		},
+		{
+			testName:       "Close account",
+			body:           `{"status": "closed"}`,
+			authorization:  adminBearer(t),
+			expectedStatus: http.StatusOK,
+			paymentStatus:  http.StatusForbidden,
+		},
+		{
+			testName:       "Reopen closed account",
+			body:           `{"status": "active"}`,
+			authorization:  adminBearer(t),
+			expectedStatus: http.StatusConflict,
+		},
+	}
+
+	for _, ts := range testSuite {
+		t.Run(ts.testName, func(t *testing.T) {
+			status := doJSON(t, &client, "PATCH", updateURL, ts.authorization, ts.body, nil)
+			if ts.expectedStatus != status {
+				t.Fatalf("wrong status code: expected: %d, actual: %d", ts.expectedStatus, status)
+			}
+			if ts.paymentStatus == 0 {
+				return
+			}
+
+			p := payload{State: "win", Amount: "1", Currency: testCurrency, TransactionID: uuid.NewV4().String()}
+			if status := postPayment(t, &client, owner, p); ts.paymentStatus != status {
+				t.Errorf("wrong status code of payment: expected: %d, actual: %d", ts.paymentStatus, status)
+			}
+		})
+	}
+}
+
+func TestAccountLimits(t *testing.T) {
+	client := http.Client{Timeout: time.Duration(10) * time.Second}
+
+	var created accountResponse
+	status := doJSON(t, &client, "POST", accountURL, adminBearer(t), `{"currencies":["EUR"]}`, &created)
+	if status != http.StatusCreated {
+		t.Fatalf("wrong status code: expected: %d, actual: %d", http.StatusCreated, status)
+	}
+	owner := "Bearer " + token(t, authSecret(), auth.Principal{AccountID: created.Account.ID, Role: auth.RolePlayer})
+	limitsURL := fmt.Sprintf("%s/%d/limits", accountURL, created.Account.ID)
+
+	topUp := payload{State: "win", Amount: "100", Currency: testCurrency, TransactionID: uuid.NewV4().String()}
+	if status := postPayment(t, &client, owner, topUp); status != http.StatusOK {
+		t.Fatalf("wrong status code: expected: %d, actual: %d", http.StatusOK, status)
+	}
+
+	testSuite := []struct {
+		testName       string
+		limit          string
+		authorization  string
+		expectedStatus int
+		payment        payload
+		paymentStatus  int
+	}{
+		{
+			testName:       "Set max stake",
+			limit:          `{"kind": "max_stake", "currency": "EUR", "amount": "10"}`,
+			authorization:  owner,
+			expectedStatus: http.StatusOK,
+			payment:        payload{State: "lost", Amount: "11"},
+			paymentStatus:  http.StatusUnprocessableEntity,
+		},
+		{
+			testName:       "Set daily loss",
+			limit:          `{"kind": "daily_loss", "currency": "EUR", "amount": "8"}`,
+			authorization:  owner,
+			expectedStatus: http.StatusOK,
+			payment:        payload{State: "lost", Amount: "5"},
+			paymentStatus:  http.StatusOK,
+		},
+		{
+			testName:       "Raise daily loss",
+			limit:          `{"kind": "daily_loss", "currency": "EUR", "amount": "50"}`,
+			authorization:  owner,
+			expectedStatus: http.StatusOK,
+			payment:        payload{State: "lost", Amount: "5"},
+			paymentStatus:  http.StatusUnprocessableEntity,
+		},
+		{
+			testName:       "Win above limits",
+			limit:          `{"kind": "daily_loss", "currency": "EUR", "amount": "4"}`,
+			authorization:  adminBearer(t),
+			expectedStatus: http.StatusOK,
+			payment:        payload{State: "win", Amount: "20"},
+			paymentStatus:  http.StatusOK,
+		},
+		{
+			testName:       "Set limit of foreign account",
+			limit:          `{"kind": "daily_loss", "currency": "EUR", "amount": "100"}`,
+			authorization:  bearer(t),
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			testName:       "Set limit of unknown kind",
+			limit:          `{"kind": "hourly_loss", "currency": "EUR", "amount": "10"}`,
+			authorization:  owner,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			testName:       "Set negative limit",
+			limit:          `{"kind": "max_stake", "currency": "EUR", "amount": "-10"}`,
+			authorization:  owner,
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, ts := range testSuite {
+		t.Run(ts.testName, func(t *testing.T) {
+			status := doJSON(t, &client, "PUT", limitsURL, ts.authorization, ts.limit, nil)
+			if ts.expectedStatus != status {
+				t.Fatalf("wrong status code: expected: %d, actual: %d", ts.expectedStatus, status)
+			}
+			if ts.paymentStatus == 0 {
+				return
+			}
+
+			p := ts.payment
+			p.Currency = testCurrency
+			p.TransactionID = uuid.NewV4().String()
+			if status := postPayment(t, &client, owner, p); ts.paymentStatus != status {
+				t.Errorf("wrong status code of payment: expected: %d, actual: %d", ts.paymentStatus, status)
+			}
+		})
+	}
+
+	var limits struct {
+		Limits []struct {
+			Kind          string `json:"kind"`
+			Amount        string `json:"amount"`
+			PendingAmount string `json:"pendingAmount"`
+		} `json:"limits"`
+	}
+	if status := doJSON(t, &client, "GET", limitsURL, owner, "", &limits); status != http.StatusOK {
+		t.Fatalf("wrong status code: expected: %d, actual: %d", http.StatusOK, status)
+	}
+	expected := map[string]string{"daily_loss": "4.00/", "max_stake": "10.00/"}
+	if len(limits.Limits) != len(expected) {
+		t.Fatalf("wrong limits: %+v", limits.Limits)
+	}
+	for _, l := range limits.Limits {
+		if actual := l.Amount + "/" + l.PendingAmount; expected[l.Kind] != actual {
+			t.Errorf("wrong %s limit: expected: %s, actual: %s", l.Kind, expected[l.Kind], actual)
+		}
+	}
+}
+
+func TestAccountExclusion(t *testing.T) {
+	client := http.Client{Timeout: time.Duration(10) * time.Second}
+
+	var created accountResponse
+	status := doJSON(t, &client, "POST", accountURL, adminBearer(t), `{"currencies":["EUR"]}`, &created)
+	if status != http.StatusCreated {
+		t.Fatalf("wrong status code: expected: %d, actual: %d", http.StatusCreated, status)
+	}
+	owner := "Bearer " + token(t, authSecret(), auth.Principal{AccountID: created.Account.ID, Role: auth.RolePlayer})
+	exclusionURL := fmt.Sprintf("%s/%d/exclusion", accountURL, created.Account.ID)
+
+	win := payload{State: "win", Amount: "10", Currency: testCurrency, TransactionID: uuid.NewV4().String()}
+	if status := postPayment(t, &client, owner, win); status != http.StatusOK {
+		t.Fatalf("wrong status code: expected: %d, actual: %d", http.StatusOK, status)
+	}
+
+	testSuite := []struct {
+		testName       string
+		body           string
+		authorization  string
+		expectedStatus int
+	}{
+		{
+			testName:       "Exclude foreign account",
+			body:           `{"days": 1}`,
+			authorization:  bearer(t),
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			testName:       "Exclude without period",
+			body:           `{}`,
+			authorization:  owner,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			testName:       "Exclude for days and permanently",
+			body:           `{"days": 1, "permanent": true}`,
+			authorization:  owner,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			testName:       "Exclude for a week",
+			body:           `{"days": 7}`,
+			authorization:  owner,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			testName:       "Shorten exclusion",
+			body:           `{"days": 1}`,
+			authorization:  owner,
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			testName:       "Exclude permanently",
+			body:           `{"permanent": true}`,
+			authorization:  owner,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			testName:       "Lift permanent exclusion by admin",
+			body:           `{"days": 1}`,
+			authorization:  adminBearer(t),
+			expectedStatus: http.StatusConflict,
+		},
+	}
+
+	for _, ts := range testSuite {
+		t.Run(ts.testName, func(t *testing.T) {
+			status := doJSON(t, &client, "PUT", exclusionURL, ts.authorization, ts.body, nil)
+			if ts.expectedStatus != status {
+				t.Errorf("wrong status code: expected: %d, actual: %d", ts.expectedStatus, status)
+			}
+		})
+	}
+
+	var state struct {
+		Exclusion struct {
+			Excluded  bool `json:"excluded"`
+			Permanent bool `json:"permanent"`
+		} `json:"exclusion"`
+	}
+	if status := doJSON(t, &client, "GET", exclusionURL, owner, "", &state); status != http.StatusOK {
+		t.Fatalf("wrong status code: expected: %d, actual: %d", http.StatusOK, status)
+	}
+	if !state.Exclusion.Excluded || !state.Exclusion.Permanent {
+		t.Errorf("wrong exclusion: %+v", state.Exclusion)
+	}
+
+	var history struct {
+		History []struct {
+			ActorRole string `json:"actorRole"`
+		} `json:"history"`
+	}
+	if status := doJSON(t, &client, "GET", exclusionURL+"/history", adminBearer(t), "", &history); status != http.StatusOK {
+		t.Fatalf("wrong status code: expected: %d, actual: %d", http.StatusOK, status)
+	}
+	if len(history.History) != 2 || history.History[0].ActorRole != "player" {
+		t.Errorf("wrong history: %+v", history.History)
+	}
+
+	for _, state := range []string{"win", "lost"} {
+		p := payload{State: state, Amount: "1", Currency: testCurrency, TransactionID: uuid.NewV4().String()}
+		if status := postPayment(t, &client, owner, p); status != http.StatusForbidden {
+			t.Errorf("wrong status code of %s payment: expected: %d, actual: %d", state, http.StatusForbidden, status)
+		}
+	}
+
+	status = doJSON(t, &client, "POST", paymentURL+"/"+win.TransactionID+"/cancel", adminBearer(t), "", nil)
+	if status != http.StatusOK {
+		t.Errorf("wrong status code of cancellation: expected: %d, actual: %d", http.StatusOK, status)
+	}
+}
+
+func TestPaymentHistory(t *testing.T) {
+	client := http.Client{Timeout: time.Duration(10) * time.Second}
+	authorization := bearer(t)
+	from := url.QueryEscape(time.Now().Add(-time.Minute).UTC().Format(time.RFC3339))
+
+	transactionIDs := make([]string, 0, 3)
+	for _, state := range []string{"win", "win", "lost"} {
+		p := payload{State: state, Amount: "1", Currency: testCurrency, TransactionID: uuid.NewV4().String()}
+		if status := postPayment(t, &client, authorization, p); status != http.StatusOK {
+			t.Fatalf("wrong status code: expected: %d, actual: %d", http.StatusOK, status)
+		}
+		transactionIDs = append(transactionIDs, p.TransactionID)
+	}
+
+	var first historyResponse
+	status := doJSON(t, &client, "GET", paymentURL+"?limit=2&createdFrom="+from, authorization, "", &first)
+	if status != http.StatusOK {
+		t.Fatalf("wrong status code: expected: %d, actual: %d", http.StatusOK, status)
+	}
+	if len(first.Payments) != 2 || first.NextCursor == "" {
+		t.Fatalf("wrong first page: %+v", first)
+	}
+	if first.Payments[0].TransactionID != transactionIDs[2] || first.Payments[1].TransactionID != transactionIDs[1] {
+		t.Errorf("wrong order of payments: %+v", first.Payments)
+	}
+
+	var second historyResponse
+	status = doJSON(t, &client, "GET", paymentURL+"?limit=2&cursor="+first.NextCursor, authorization, "", &second)
+	if status != http.StatusOK {
+		t.Fatalf("wrong status code: expected: %d, actual: %d", http.StatusOK, status)
+	}
+	if len(second.Payments) == 0 || second.Payments[0].TransactionID != transactionIDs[0] {
+		t.Errorf("wrong second page: %+v", second)
+	}
+
+	var lost historyResponse
+	status = doJSON(t, &client, "GET", paymentURL+"?state=lost&sourceType=payment&createdFrom="+from, authorization, "", &lost)
+	if status != http.StatusOK {
+		t.Fatalf("wrong status code: expected: %d, actual: %d", http.StatusOK, status)
+	}
+	for _, p := range lost.Payments {
+		if p.State != "lost" {
+			t.Errorf("payment doesn't match filter: %+v", p)
+		}
+	}
+
+	for _, query := range []string{"?state=draw", "?cursor=abc", "?createdFrom=yesterday", "?status=maybe"} {
+		status = doJSON(t, &client, "GET", paymentURL+query, authorization, "", nil)
+		if status != http.StatusBadRequest {
+			t.Errorf("wrong status code for %s: expected: %d, actual: %d", query, http.StatusBadRequest, status)
+		}
+	}
+
+	if status = doJSON(t, &client, "GET", paymentURL, adminBearer(t), "", nil); status != http.StatusForbidden {
+		t.Errorf("wrong status code of admin history: expected: %d, actual: %d", http.StatusForbidden, status)
+	}
+
+	p := payload{State: "win", Amount: "1", Currency: testCurrency, TransactionID: uuid.NewV4().String()}
+	if status = postPayment(t, &client, adminBearer(t), p); status != http.StatusForbidden {
+		t.Errorf("wrong status code of admin payment: expected: %d, actual: %d", http.StatusForbidden, status)
+	}
+}
+
+func TestPaymentLookup(t *testing.T) {
+	client := http.Client{Timeout: time.Duration(10) * time.Second}
+	authorization := bearer(t)
+
+	p := payload{State: "win", Amount: "2.50", Currency: testCurrency, TransactionID: uuid.NewV4().String()}
+	if status := postPayment(t, &client, authorization, p); status != http.StatusOK {
+		t.Fatalf("wrong status code: expected: %d, actual: %d", http.StatusOK, status)
+	}
+
+	var r struct {
+		Status  bool `json:"status"`
+		Payment struct {
+			TransactionID string `json:"transactionId"`
+			State         string `json:"state"`
+			Amount        string `json:"amount"`
+			Status        string `json:"status"`
+		} `json:"payment"`
+	}
+	status := doJSON(t, &client, "GET", paymentURL+"/"+p.TransactionID, authorization, "", &r)
+	if status != http.StatusOK {
+		t.Fatalf("wrong status code: expected: %d, actual: %d", http.StatusOK, status)
+	}
+	if r.Payment.TransactionID != p.TransactionID || r.Payment.Amount != p.Amount || r.Payment.Status != "accepted" {
+		t.Errorf("wrong payment: %+v", r.Payment)
+	}
+
+	status = doJSON(t, &client, "GET", paymentURL+"/"+uuid.NewV4().String(), authorization, "", nil)
+	if status != http.StatusNotFound {
+		t.Errorf("wrong status code: expected: %d, actual: %d", http.StatusNotFound, status)
+	}
+}
+
+func TestPaymentCancellation(t *testing.T) {
+	client := http.Client{Timeout: time.Duration(10) * time.Second}
+	authorization := bearer(t)
+
+	balanceBefore, err := getBalance(&client, authorization)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := payload{State: "win", Amount: "5", Currency: testCurrency, TransactionID: uuid.NewV4().String()}
+	if status := postPayment(t, &client, authorization, p); status != http.StatusOK {
+		t.Fatalf("wrong status code: expected: %d, actual: %d", http.StatusOK, status)
+	}
+
+	testSuite := []struct {
+		testName       string
+		transactionID  string
+		authorization  string
+		expectedStatus int
+	}{
+		{
+			testName:       "Cancel by player",
+			transactionID:  p.TransactionID,
+			authorization:  authorization,
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			testName:       "Cancel payment",
+			transactionID:  p.TransactionID,
+			authorization:  adminBearer(t),
+			expectedStatus: http.StatusOK,
+		},
+		{
+			testName:       "Cancel payment twice",
+			transactionID:  p.TransactionID,
+			authorization:  adminBearer(t),
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			testName:       "Cancel unknown payment",
+			transactionID:  uuid.NewV4().String(),
+			authorization:  adminBearer(t),
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, ts := range testSuite {
+		t.Run(ts.testName, func(t *testing.T) {
+			status := doJSON(t, &client, "POST", paymentURL+"/"+ts.transactionID+"/cancel", ts.authorization, "", nil)
+			if ts.expectedStatus != status {
+				t.Errorf("wrong status code: expected: %d, actual: %d", ts.expectedStatus, status)
+			}
+		})
+	}
+
+	var r struct {
+		Payment struct {
+			Status string `json:"status"`
+		} `json:"payment"`
+	}
+	doJSON(t, &client, "GET", paymentURL+"/"+p.TransactionID, authorization, "", &r)
+	if r.Payment.Status != "cancelled" {
+		t.Errorf("wrong payment status: expected: cancelled, actual: %s", r.Payment.Status)
+	}
+
+	balanceAfter, err := getBalance(&client, authorization)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if fmt.Sprintf("%.2f", balanceBefore) != fmt.Sprintf("%.2f", balanceAfter) {
+		t.Errorf("cancellation wasn't reversed: expected balance: %.2f, actual: %.2f", balanceBefore, balanceAfter)
+	}
+}
+
+func TestLedgerReconciliation(t *testing.T) {
+	client := http.Client{Timeout: time.Duration(10) * time.Second}
+
+	var r struct {
+		Status     bool              `json:"status"`
+		Mismatches []json.RawMessage `json:"mismatches"`
+	}
+	status := doJSON(t, &client, "GET", ledgerURL+"/mismatches", adminBearer(t), "", &r)
+	if status != http.StatusOK {
+		t.Fatalf("wrong status code: expected: %d, actual: %d", http.StatusOK, status)
+	}
+	if len(r.Mismatches) != 0 {
+		t.Errorf("balances differ from ledger: %s", r.Mismatches)
+	}
+
+	status = doJSON(t, &client, "GET", ledgerURL+"/mismatches", bearer(t), "", nil)
+	if status != http.StatusForbidden {
+		t.Errorf("wrong status code: expected: %d, actual: %d", http.StatusForbidden, status)
+	}
+}
+
+func TestProcessingRuns(t *testing.T) {
+	client := http.Client{Timeout: time.Duration(10) * time.Second}
+
+	var r struct {
+		Status bool              `json:"status"`
+		Runs   []json.RawMessage `json:"runs"`
+	}
+	status := doJSON(t, &client, "GET", runsURL+"?limit=5", adminBearer(t), "", &r)
+	if status != http.StatusOK {
+		t.Fatalf("wrong status code: expected: %d, actual: %d", http.StatusOK, status)
+	}
+	if !r.Status || r.Runs == nil || len(r.Runs) > 5 {
+		t.Errorf("wrong runs response: %+v", r)
+	}
+
+	testSuite := []struct {
+		testName       string
+		url            string
+		authorization  string
+		expectedStatus int
+	}{
+		{
+			testName:       "List runs by player",
+			url:            runsURL,
+			authorization:  bearer(t),
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			testName:       "List runs with incorrect limit",
+			url:            runsURL + "?limit=-1",
+			authorization:  adminBearer(t),
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			testName:       "Get unknown run",
+			url:            runsURL + "/2147483647",
+			authorization:  adminBearer(t),
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			testName:       "Get run with incorrect id",
+			url:            runsURL + "/abc",
+			authorization:  adminBearer(t),
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, ts := range testSuite {
+		t.Run(ts.testName, func(t *testing.T) {
+			status := doJSON(t, &client, "GET", ts.url, ts.authorization, "", nil)
+			if ts.expectedStatus != status {
+				t.Errorf("wrong status code: expected: %d, actual: %d", ts.expectedStatus, status)
+			}
+		})
+	}
+}
+
+func TestProcessingDryRun(t *testing.T) {
+	client := http.Client{Timeout: time.Duration(10) * time.Second}
+
+	balanceBefore, err := getBalance(&client, bearer(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var r struct {
+		Status  bool `json:"status"`
+		Preview struct {
+			Candidates int               `json:"candidates"`
+			Items      []json.RawMessage `json:"items"`
+			Balances   []json.RawMessage `json:"balances"`
+		} `json:"preview"`
+	}
+	status := doJSON(t, &client, "POST", runsURL+"?dryRun=true", adminBearer(t), "", &r)
+	if status != http.StatusOK {
+		t.Fatalf("wrong status code: expected: %d, actual: %d", http.StatusOK, status)
+	}
+	if !r.Status || r.Preview.Candidates != len(r.Preview.Items) {
+		t.Errorf("wrong preview response: %+v", r)
+	}
+
+	balanceAfter, err := getBalance(&client, bearer(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if balanceAfter != balanceBefore {
+		t.Errorf("dry run changed balance from %.2f to %.2f", balanceBefore, balanceAfter)
+	}
+
+	testSuite := []struct {
+		testName       string
+		url            string
+		authorization  string
+		expectedStatus int
+	}{
+		{
+			testName:       "Trigger run by player",
+			url:            runsURL + "?dryRun=true",
+			authorization:  bearer(t),
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			testName:       "Trigger run with incorrect dryRun",
+			url:            runsURL + "?dryRun=maybe",
+			authorization:  adminBearer(t),
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, ts := range testSuite {
+		t.Run(ts.testName, func(t *testing.T) {
+			status := doJSON(t, &client, "POST", ts.url, ts.authorization, "", nil)
+			if ts.expectedStatus != status {
+				t.Errorf("wrong status code: expected: %d, actual: %d", ts.expectedStatus, status)
+			}
+		})
+	}
+}
+
+func TestSourceTypes(t *testing.T) {
+	client := http.Client{Timeout: time.Duration(10) * time.Second}
+	value := "test-" + strings.ToLower(uuid.NewV4().String()[:8])
+
+	var created struct {
+		SourceType struct {
+			ID      int    `json:"id"`
+			Value   string `json:"value"`
+			Enabled bool   `json:"enabled"`
+		} `json:"sourceType"`
+	}
+	body := fmt.Sprintf(`{"value": %q}`, value)
+	if status := doJSON(t, &client, "POST", sourcesURL, adminBearer(t), body, &created); status != http.StatusCreated {
+		t.Fatalf("wrong status code: expected: %d, actual: %d", http.StatusCreated, status)
+	}
+	if created.SourceType.Value != value || !created.SourceType.Enabled {
+		t.Fatalf("wrong created source type: %+v", created.SourceType)
+	}
+
+	p := payload{State: "win", Amount: "1", Currency: testCurrency, TransactionID: uuid.NewV4().String()}
+	if status := postPaymentFrom(t, &client, bearer(t), value, p); status != http.StatusOK {
+		t.Errorf("wrong status code of payment from new source: expected: %d, actual: %d", http.StatusOK, status)
+	}
+
+	sourceURL := fmt.Sprintf("%s/%d", sourcesURL, created.SourceType.ID)
+	if status := doJSON(t, &client, "PATCH", sourceURL, adminBearer(t), `{"enabled": false}`, nil); status != http.StatusOK {
+		t.Fatalf("wrong status code: expected: %d, actual: %d", http.StatusOK, status)
+	}
+
+	p = payload{State: "lost", Amount: "1", Currency: testCurrency, TransactionID: uuid.NewV4().String()}
+	if status := postPaymentFrom(t, &client, bearer(t), value, p); status != http.StatusBadRequest {
+		t.Errorf("wrong status code of payment from disabled source: expected: %d, actual: %d",
+			http.StatusBadRequest, status)
+	}
+
+	restore := payload{State: "lost", Amount: "1", Currency: testCurrency, TransactionID: uuid.NewV4().String()}
+	if status := postPayment(t, &client, bearer(t), restore); status != http.StatusOK {
+		t.Fatalf("wrong status code: expected: %d, actual: %d", http.StatusOK, status)
+	}
+
+	testSuite := []struct {
+		testName       string
+		method         string
+		url            string
+		body           string
+		authorization  string
+		expectedStatus int
+	}{
+		{
+			testName:       "List source types",
+			method:         "GET",
+			url:            sourcesURL,
+			authorization:  adminBearer(t),
+			expectedStatus: http.StatusOK,
+		},
+		{
+			testName:       "List source types by player",
+			method:         "GET",
+			url:            sourcesURL,
+			authorization:  bearer(t),
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			testName:       "Create existing source type",
+			method:         "POST",
+			url:            sourcesURL,
+			body:           body,
+			authorization:  adminBearer(t),
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			testName:       "Create source type with invalid value",
+			method:         "POST",
+			url:            sourcesURL,
+			body:           `{"value": "Casino Live"}`,
+			authorization:  adminBearer(t),
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			testName:       "Disable unknown source type",
+			method:         "PATCH",
+			url:            sourcesURL + "/2147483647",
+			body:           `{"enabled": false}`,
+			authorization:  adminBearer(t),
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			testName:       "Update source type without enabled",
+			method:         "PATCH",
+			url:            sourceURL,
+			body:           `{}`,
+			authorization:  adminBearer(t),
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, ts := range testSuite {
+		t.Run(ts.testName, func(t *testing.T) {
+			status := doJSON(t, &client, ts.method, ts.url, ts.authorization, ts.body, nil)
+			if ts.expectedStatus != status {
+				t.Errorf("wrong status code: expected: %d, actual: %d", ts.expectedStatus, status)
+			}
+		})
+	}
+}
+
+func TestRequestSigning(t *testing.T) {
+	client := http.Client{Timeout: time.Duration(10) * time.Second}
+	secret := secretFor(t, "payment")
+	nonce := uuid.NewV4().String()
+	now := time.Now()
+
+	testSuite := []struct {
+		testName       string
+		state          string
+		secret         string
+		timestamp      time.Time
+		nonce          string
+		unsigned       bool
+		rotate         bool
+		expectedStatus int
+		expectedCode   string
+	}{
+		{
+			testName:       "Unsigned request",
+			state:          "win",
+			unsigned:       true,
+			expectedStatus: http.StatusUnauthorized,
+			expectedCode:   "missing_signature",
+		},
+		{
+			testName:       "Request signed with wrong secret",
+			state:          "win",
+			secret:         "wrong-secret",
+			timestamp:      now,
+			nonce:          uuid.NewV4().String(),
+			expectedStatus: http.StatusUnauthorized,
+			expectedCode:   "invalid_signature",
+		},
+		{
+			testName:       "Request with stale timestamp",
+			state:          "win",
+			secret:         secret,
+			timestamp:      now.Add(-time.Hour),
+			nonce:          uuid.NewV4().String(),
+			expectedStatus: http.StatusUnauthorized,
+			expectedCode:   "stale_timestamp",
+		},
+		{
+			testName:       "Signed request",
+			state:          "win",
+			secret:         secret,
+			timestamp:      now,
+			nonce:          nonce,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			testName:       "Request with replayed nonce",
+			state:          "lost",
+			secret:         secret,
+			timestamp:      now,
+			nonce:          nonce,
+			expectedStatus: http.StatusUnauthorized,
+			expectedCode:   "replayed_nonce",
+		},
+		{
+			testName:       "Request signed with rotated secret",
+			state:          "lost",
+			secret:         secret,
+			timestamp:      now,
+			nonce:          uuid.NewV4().String(),
+			rotate:         true,
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	for _, ts := range testSuite {
+		t.Run(ts.testName, func(t *testing.T) {
+			if ts.rotate {
+				rotateSecret(t, &client, "payment")
+			}
+			p := payload{State: ts.state, Amount: "1", Currency: testCurrency, TransactionID: uuid.NewV4().String()}
+			body, err := json.Marshal(p)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req, err := http.NewRequest("POST", paymentURL, bytes.NewBuffer(body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Source-Type", "payment")
+			req.Header.Set("Authorization", bearer(t))
+			if !ts.unsigned {
+				timestamp := strconv.FormatInt(ts.timestamp.Unix(), 10)
+				req.Header.Set("Signature-Timestamp", timestamp)
+				req.Header.Set("Signature-Nonce", ts.nonce)
+				req.Header.Set("Signature", signature.Sign(ts.secret, timestamp, ts.nonce, body))
+			}
+
+			resp, err := client.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer func() {
+				_ = resp.Body.Close()
+			}()
+
+			if ts.expectedStatus != resp.StatusCode {
+				t.Errorf("wrong status code: expected: %d, actual: %d", ts.expectedStatus, resp.StatusCode)
+			}
+			if ts.expectedCode == "" {
+				return
+			}
+			var result struct {
+				Code string `json:"code"`
+			}
+			if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+				t.Fatal(err)
+			}
+			if ts.expectedCode != result.Code {
+				t.Errorf("wrong error code: expected: %s, actual: %s", ts.expectedCode, result.Code)
+			}
+		})
+	}
+}
+
+func TestSignatureEnforcement(t *testing.T) {
+	client := http.Client{Timeout: time.Duration(10) * time.Second}
+	value := "test-" + strings.ToLower(uuid.NewV4().String()[:8])
+
+	body := fmt.Sprintf(`{"value": %q}`, value)
+	if status := doJSON(t, &client, "POST", sourcesURL, adminBearer(t), body, nil); status != http.StatusCreated {
+		t.Fatalf("wrong status code: expected: %d, actual: %d", http.StatusCreated, status)
+	}
+
+	postUnsigned := func() int {
+		p := payload{State: "win", Amount: "1", Currency: testCurrency, TransactionID: uuid.NewV4().String()}
+		b, err := json.Marshal(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req, err := http.NewRequest("POST", paymentURL, bytes.NewBuffer(b))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Source-Type", value)
+		req.Header.Set("Authorization", bearer(t))
+
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	if status := postUnsigned(); status != http.StatusUnauthorized {
+		t.Errorf("wrong status code of unsigned payment without secret: expected: %d, actual: %d",
+			http.StatusUnauthorized, status)
+	}
+
+	p := payload{State: "win", Amount: "1", Currency: testCurrency, TransactionID: uuid.NewV4().String()}
+	if status := postPaymentFrom(t, &client, bearer(t), value, p); status != http.StatusOK {
+		t.Errorf("wrong status code of signed payment: expected: %d, actual: %d", http.StatusOK, status)
+	}
+
+	if status := postUnsigned(); status != http.StatusUnauthorized {
+		t.Errorf("wrong status code of unsigned payment: expected: %d, actual: %d", http.StatusUnauthorized, status)
+	}
+}
+
+func TestRejectedPaymentRetry(t *testing.T) {
+	client := http.Client{Timeout: time.Duration(10) * time.Second}
+	authorization := bearer(t)
+
+	balanceBefore, err := getBalance(&client, authorization)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := payload{
+		State:         "lost",
+		Amount:        fmt.Sprintf("%.2f", balanceBefore+1),
+		Currency:      testCurrency,
+		TransactionID: uuid.NewV4().String(),
+	}
+	if status := postPayment(t, &client, authorization, p); status != http.StatusPaymentRequired {
+		t.Fatalf("wrong status code: expected: %d, actual: %d", http.StatusPaymentRequired, status)
+	}
+
+	topUp := payload{State: "win", Amount: "1", Currency: testCurrency, TransactionID: uuid.NewV4().String()}
+	if status := postPayment(t, &client, authorization, topUp); status != http.StatusOK {
+		t.Fatalf("wrong status code: expected: %d, actual: %d", http.StatusOK, status)
+	}
+
+	if status := postPayment(t, &client, authorization, p); status != http.StatusOK {
+		t.Fatalf("wrong status code of retry: expected: %d, actual: %d", http.StatusOK, status)
+	}
+
+	var r struct {
+		Payment struct {
+			Status   string `json:"status"`
+			Attempts []struct {
+				Status string `json:"status"`
+				Reason string `json:"reason"`
+			} `json:"attempts"`
+		} `json:"payment"`
+	}
+	doJSON(t, &client, "GET", paymentURL+"/"+p.TransactionID, authorization, "", &r)
+	if r.Payment.Status != "accepted" || len(r.Payment.Attempts) != 2 ||
+		r.Payment.Attempts[0].Status != "rejected" || r.Payment.Attempts[1].Status != "accepted" {
+		t.Errorf("wrong retried payment: %+v", r.Payment)
+	}
+
+	p.State = "win"
+	if status := postPayment(t, &client, authorization, p); status != http.StatusConflict {
+		t.Errorf("wrong status code of resubmitted accepted payment: expected: %d, actual: %d", http.StatusConflict, status)
+	}
+
+	restore := payload{State: "win", Amount: p.Amount, Currency: testCurrency, TransactionID: uuid.NewV4().String()}
+	if status := postPayment(t, &client, authorization, restore); status != http.StatusOK {
+		t.Fatalf("wrong status code: expected: %d, actual: %d", http.StatusOK, status)
+	}
+}
+
+type historyResponse struct {
+	Status   bool `json:"status"`
+	Payments []struct {
+		TransactionID string `json:"transactionId"`
+		State         string `json:"state"`
+		Amount        string `json:"amount"`
+	} `json:"payments"`
+	NextCursor string `json:"nextCursor"`
+}
+
+type balance struct {
+	Currency string `json:"currency"`
+	Balance  string `json:"balance"`
+}
+
+type account struct {
+	ID       int       `json:"id"`
+	Status   string    `json:"status"`
+	Balances []balance `json:"balances"`
+}
+
+type accountResponse struct {
+	Status  bool    `json:"status"`
+	Account account `json:"account"`
+}
+
+var (
+	player = auth.Principal{AccountID: testAccountID, Role: auth.RolePlayer}
+	admin  = auth.Principal{Role: auth.RoleAdmin}
+)
+
+func bearer(t *testing.T) string {
+	return "Bearer " + token(t, authSecret(), player)
+}
+
+func adminBearer(t *testing.T) string {
+	return "Bearer " + token(t, authSecret(), admin)
+}
+
+func authSecret() string {
+	if secret := os.Getenv("AUTH_SECRET"); secret != "" {
+		return secret
+	}
+
+	return defaultAuthSecret
+}
+
+func token(t *testing.T, secret string, principal auth.Principal) string {
+	tkn, err := auth.NewToken(secret, principal, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return tkn
+}
+
+func getBalance(client *http.Client, authorization string) (float64, error) {
+	req, err := http.NewRequest("GET", balanceURL, nil)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Authorization", authorization)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	var r balancesResponse
+	if err := json.Unmarshal(body, &r); err != nil {
+		return 0, err
+	}
+
+	for _, b := range r.Balances {
+		if b.Currency == testCurrency {
+			return strconv.ParseFloat(b.Balance, 64)
+		}
+	}
+
+	return 0, fmt.Errorf("no %s balance in response: %s", testCurrency, body)
+}
+
+func postPayment(t *testing.T, client *http.Client, authorization string, p payload) int {
+	return postPaymentFrom(t, client, authorization, "payment", p)
+}
+
+func postPaymentFrom(t *testing.T, client *http.Client, authorization, sourceType string, p payload) int {
+	return sendPayment(t, client, authorization, sourceType, p, nil)
+}
+
+// sendPayment posts signed payment from source type and decodes response into out unless it's nil.
+func sendPayment(
+	t *testing.T, client *http.Client, authorization, sourceType string, p payload, out interface{},
+) int {
+	body, err := json.Marshal(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req, err := http.NewRequest("POST", paymentURL, bytes.NewBuffer(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Source-Type", sourceType)
+	req.Header.Set("Authorization", authorization)
+	signRequest(req, secretFor(t, sourceType), body)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return resp.StatusCode
+}
+
+var (
+	sourceSecretsMu sync.Mutex
+	sourceSecrets   = make(map[string]string)
+)
+
+// secretFor returns signing secret of source type, rotating it once per test run.
+func secretFor(t *testing.T, sourceType string) string {
+	sourceSecretsMu.Lock()
+	defer sourceSecretsMu.Unlock()
+
+	if secret, ok := sourceSecrets[sourceType]; ok {
+		return secret
+	}
+
+	client := http.Client{Timeout: time.Duration(10) * time.Second}
+	sourceSecrets[sourceType] = rotateSecret(t, &client, sourceType)
+
+	return sourceSecrets[sourceType]
+}
+
+// rotateSecret issues a new signing secret of source type and returns it.
+// Unknown source type has no secret.
+func rotateSecret(t *testing.T, client *http.Client, sourceType string) string {
+	id, ok := sourceTypeID(t, client, sourceType)
+	if !ok {
+		return ""
+	}
+
+	var rotated struct {
+		Secret struct {
+			Secret string `json:"secret"`
+		} `json:"secret"`
+	}
+	url := fmt.Sprintf("%s/%d/secrets", sourcesURL, id)
+	if status := doJSON(t, client, "POST", url, adminBearer(t), "", &rotated); status != http.StatusCreated {
+		t.Fatalf("wrong status code: expected: %d, actual: %d", http.StatusCreated, status)
+	}
+
+	return rotated.Secret.Secret
+}
+
+// sourceTypeID returns id of source type by its value.
+func sourceTypeID(t *testing.T, client *http.Client, sourceType string) (int, bool) {
+	var list struct {
+		SourceTypes []struct {
+			ID    int    `json:"id"`
+			Value string `json:"value"`
+		} `json:"sourceTypes"`
+	}
+	if status := doJSON(t, client, "GET", sourcesURL, adminBearer(t), "", &list); status != http.StatusOK {
+		t.Fatalf("wrong status code: expected: %d, actual: %d", http.StatusOK, status)
+	}
+
+	for _, st := range list.SourceTypes {
+		if st.Value == sourceType {
+			return st.ID, true
+		}
+	}
+
+	return 0, false
+}
+
+func signRequest(req *http.Request, secret string, body []byte) {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	nonce := uuid.NewV4().String()
+	req.Header.Set("Signature-Timestamp", timestamp)
+	req.Header.Set("Signature-Nonce", nonce)
+	req.Header.Set("Signature", signature.Sign(secret, timestamp, nonce, body))
+}
+
+func doJSON(t *testing.T, client *http.Client, method, url, authorization, body string, out interface{}) int {
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", authorization)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			t.Fatal(err)
+		}
 	}
 
-	return strconv.ParseFloat(r.Message, 64)
+	return resp.StatusCode
 }
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"github.com/go-pg/pg/v9/orm"
+	"github.com/robinjoseph08/go-pg-migrations/v2"
+)
+
+func init() {
+	up := func(db orm.DB) error {
+		_, err := db.Exec(`ALTER TABLE accounts
+			    ADD COLUMN created_at timestamptz not null default now(),
+			    ADD COLUMN updated_at timestamptz not null default now(),
+			    ADD COLUMN status     text        not null default 'active'
+			        CHECK (status IN ('active', 'blocked', 'closed'));
+		`)
+
+		return err
+	}
+
+	down := func(db orm.DB) error {
+		_, err := db.Exec(`ALTER TABLE accounts
+			    DROP COLUMN IF EXISTS created_at,
+			    DROP COLUMN IF EXISTS updated_at,
+			    DROP COLUMN IF EXISTS status;
+		`)
+		return err
+	}
+
+	opts := migrations.MigrationOptions{}
+
+	migrations.Register("000004_alter_accounts_table", up, down, opts)
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"github.com/go-pg/pg/v9/orm"
+	"github.com/robinjoseph08/go-pg-migrations/v2"
+)
+
+func init() {
+	up := func(db orm.DB) error {
+		_, err := db.Exec(`CREATE TABLE currencies
+			(
+			    code   text primary key,
+			    places int  not null CHECK (places BETWEEN 0 AND 8)
+			);
+		`)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.Exec(`INSERT INTO currencies (code, places)
+			VALUES ('EUR', 2), ('USD', 2), ('BTC', 8), ('ETH', 8);
+		`)
+
+		return err
+	}
+
+	down := func(db orm.DB) error {
+		_, err := db.Exec("DROP TABLE IF EXISTS currencies;")
+		return err
+	}
+
+	opts := migrations.MigrationOptions{}
+
+	migrations.Register("000005_create_currencies_table", up, down, opts)
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"github.com/go-pg/pg/v9/orm"
+	"github.com/robinjoseph08/go-pg-migrations/v2"
+)
+
+func init() {
+	up := func(db orm.DB) error {
+		_, err := db.Exec(`CREATE TABLE account_balances
+			(
+			    account_id int            not null references accounts (id),
+			    currency   text           not null references currencies (code),
+			    balance    numeric(20, 8) not null default 0,
+			    created_at timestamptz    not null default now(),
+			    updated_at timestamptz    not null default now(),
+			    primary key (account_id, currency)
+			);
+		`)
+		if err != nil {
+			return err
+		}
+
+		// balances existing before multi-currency support were kept in EUR
+		_, err = db.Exec(`INSERT INTO account_balances (account_id, currency, balance)
+			SELECT id, 'EUR', balance FROM accounts;
+		`)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.Exec(`ALTER TABLE accounts DROP COLUMN balance;`)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.Exec(`ALTER TABLE payments
+			    ADD COLUMN currency text not null default 'EUR' references currencies (code);
+		`)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.Exec(`ALTER TABLE payments ALTER COLUMN currency DROP DEFAULT;`)
+
+		return err
+	}
+
+	down := func(db orm.DB) error {
+		_, err := db.Exec(`ALTER TABLE payments DROP COLUMN IF EXISTS currency;`)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.Exec(`ALTER TABLE accounts ADD COLUMN balance numeric(12,2) default 0;`)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.Exec(`UPDATE accounts a SET balance = b.balance
+			FROM account_balances b
+			WHERE b.account_id = a.id AND b.currency = 'EUR';
+		`)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.Exec("DROP TABLE IF EXISTS account_balances;")
+		return err
+	}
+
+	opts := migrations.MigrationOptions{}
+
+	migrations.Register("000006_create_account_balances_table", up, down, opts)
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"github.com/go-pg/pg/v9/orm"
+	"github.com/robinjoseph08/go-pg-migrations/v2"
+)
+
+func init() {
+	up := func(db orm.DB) error {
+		_, err := db.Exec(`CREATE TABLE ledger_entries
+			(
+			    id         bigserial primary key,
+			    created_at timestamptz    not null default now(),
+			    payment_id int            references payments (id),
+			    account_id int            not null references accounts (id),
+			    currency   text           not null references currencies (code),
+			    ledger     text           not null CHECK (ledger IN ('wallet', 'house')),
+			    kind       text           not null CHECK (kind IN ('opening', 'payment', 'cancellation')),
+			    debit      numeric(20, 8) not null default 0 CHECK (debit >= 0),
+			    credit     numeric(20, 8) not null default 0 CHECK (credit >= 0),
+			    CHECK ((debit = 0) <> (credit = 0))
+			);
+		`)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.Exec(`CREATE INDEX ledger_entries_account_idx
+			ON ledger_entries (account_id, currency, ledger);
+		`)
+		if err != nil {
+			return err
+		}
+
+		// balances accumulated before the ledger are posted as opening entries
+		_, err = db.Exec(`INSERT INTO ledger_entries (account_id, currency, ledger, kind, debit, credit)
+			SELECT account_id, currency, 'wallet', 'opening', 0, balance
+			FROM account_balances WHERE balance > 0
+			UNION ALL
+			SELECT account_id, currency, 'house', 'opening', balance, 0
+			FROM account_balances WHERE balance > 0;
+		`)
+
+		return err
+	}
+
+	down := func(db orm.DB) error {
+		_, err := db.Exec("DROP TABLE IF EXISTS ledger_entries;")
+		return err
+	}
+
+	opts := migrations.MigrationOptions{}
+
+	migrations.Register("000007_create_ledger_entries_table", up, down, opts)
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"github.com/go-pg/pg/v9/orm"
+	"github.com/robinjoseph08/go-pg-migrations/v2"
+)
+
+func init() {
+	up := func(db orm.DB) error {
+		_, err := db.Exec(`ALTER TABLE payments ADD COLUMN cancelled_at timestamptz;`)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.Exec(`CREATE INDEX payments_account_id_idx ON payments (account_id, id);`)
+
+		return err
+	}
+
+	down := func(db orm.DB) error {
+		_, err := db.Exec(`DROP INDEX IF EXISTS payments_account_id_idx;`)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.Exec(`ALTER TABLE payments DROP COLUMN IF EXISTS cancelled_at;`)
+		return err
+	}
+
+	opts := migrations.MigrationOptions{}
+
+	migrations.Register("000008_alter_payments_table", up, down, opts)
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"github.com/go-pg/pg/v9/orm"
+	"github.com/robinjoseph08/go-pg-migrations/v2"
+)
+
+func init() {
+	up := func(db orm.DB) error {
+		_, err := db.Exec(`
+			ALTER TABLE payments
+			    ADD COLUMN status text NOT NULL DEFAULT 'accepted'
+			        CHECK (status IN ('accepted', 'rejected', 'cancelled', 'cancel_failed')),
+			    ADD COLUMN reason text NOT NULL DEFAULT '';
+		`)
+		if err != nil {
+			return err
+		}
+
+		// reversed payments have cancelled_at set, the rest of unprocessed
+		// payments were never applied, so they're rejected and can be retried
+		_, err = db.Exec(`
+			UPDATE payments
+			SET status = CASE WHEN cancelled_at IS NOT NULL THEN 'cancelled' ELSE 'rejected' END,
+			    reason = CASE WHEN cancelled_at IS NOT NULL THEN '' ELSE 'legacy: processed=false' END
+			WHERE NOT processed;
+		`)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.Exec(`ALTER TABLE payments DROP COLUMN processed;`)
+		return err
+	}
+
+	down := func(db orm.DB) error {
+		_, err := db.Exec(`ALTER TABLE payments ADD COLUMN processed bool NOT NULL DEFAULT false;`)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.Exec(`UPDATE payments SET processed = status IN ('accepted', 'cancel_failed');`)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.Exec(`ALTER TABLE payments DROP COLUMN status, DROP COLUMN reason;`)
+		return err
+	}
+
+	opts := migrations.MigrationOptions{}
+
+	migrations.Register("000009_add_payments_status_column", up, down, opts)
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"github.com/go-pg/pg/v9/orm"
+	"github.com/robinjoseph08/go-pg-migrations/v2"
+)
+
+func init() {
+	up := func(db orm.DB) error {
+		_, err := db.Exec(`CREATE TABLE payment_attempts
+			(
+			    id         serial primary key,
+			    created_at timestamptz not null default now(),
+			    payment_id int         not null references payments (id),
+			    status     text        not null CHECK (status IN ('accepted', 'rejected')),
+			    reason     text        not null default ''
+			);
+		`)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.Exec(`CREATE INDEX payment_attempts_payment_id_idx
+			ON payment_attempts (payment_id);
+		`)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.Exec(`
+			INSERT INTO payment_attempts (created_at, payment_id, status, reason)
+			SELECT created_at, id, CASE WHEN status = 'rejected' THEN 'rejected' ELSE 'accepted' END, ''
+			FROM payments;
+		`)
+		return err
+	}
+
+	down := func(db orm.DB) error {
+		_, err := db.Exec(`DROP TABLE IF EXISTS payment_attempts;`)
+		return err
+	}
+
+	opts := migrations.MigrationOptions{}
+
+	migrations.Register("000010_create_payment_attempts_table", up, down, opts)
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"github.com/go-pg/pg/v9/orm"
+	"github.com/robinjoseph08/go-pg-migrations/v2"
+)
+
+func init() {
+	up := func(db orm.DB) error {
+		_, err := db.Exec(`CREATE TABLE debts
+			(
+			    id         serial primary key,
+			    created_at timestamptz    not null default now(),
+			    account_id int            not null references accounts (id),
+			    currency   text           not null references currencies (code),
+			    payment_id int            not null references payments (id),
+			    amount     numeric(20, 8) not null CHECK (amount > 0),
+			    remaining  numeric(20, 8) not null CHECK (remaining >= 0 AND remaining <= amount),
+			    settled_at timestamptz
+			);
+		`)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.Exec(`CREATE INDEX debts_outstanding_idx
+			ON debts (account_id, currency, id) WHERE remaining > 0;
+		`)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.Exec(`ALTER TABLE ledger_entries DROP CONSTRAINT ledger_entries_kind_check;`)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.Exec(`ALTER TABLE ledger_entries ADD CONSTRAINT ledger_entries_kind_check
+			CHECK (kind IN ('opening', 'payment', 'cancellation', 'debt_collection'));
+		`)
+		return err
+	}
+
+	down := func(db orm.DB) error {
+		_, err := db.Exec(`ALTER TABLE ledger_entries DROP CONSTRAINT ledger_entries_kind_check;`)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.Exec(`ALTER TABLE ledger_entries ADD CONSTRAINT ledger_entries_kind_check
+			CHECK (kind IN ('opening', 'payment', 'cancellation'));
+		`)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.Exec(`DROP TABLE IF EXISTS debts;`)
+		return err
+	}
+
+	opts := migrations.MigrationOptions{}
+
+	migrations.Register("000011_create_debts_table", up, down, opts)
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"github.com/go-pg/pg/v9/orm"
+	"github.com/robinjoseph08/go-pg-migrations/v2"
+)
+
+func init() {
+	up := func(db orm.DB) error {
+		_, err := db.Exec(`CREATE TABLE processing_runs
+			(
+			    id          serial primary key,
+			    started_at  timestamptz not null default now(),
+			    finished_at timestamptz,
+			    candidates  int         not null default 0,
+			    cancelled   int         not null default 0,
+			    skipped     int         not null default 0,
+			    failed      int         not null default 0,
+			    error       text        not null default ''
+			);
+		`)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.Exec(`CREATE TABLE processing_run_items
+			(
+			    id             serial primary key,
+			    run_id         int  not null references processing_runs (id),
+			    payment_id     int  not null references payments (id),
+			    transaction_id text not null,
+			    outcome        text not null CHECK (outcome IN ('cancelled', 'skipped', 'failed')),
+			    reason         text not null default ''
+			);
+		`)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.Exec(`CREATE INDEX processing_run_items_run_id_idx
+			ON processing_run_items (run_id);
+		`)
+		return err
+	}
+
+	down := func(db orm.DB) error {
+		_, err := db.Exec(`DROP TABLE IF EXISTS processing_run_items;`)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.Exec(`DROP TABLE IF EXISTS processing_runs;`)
+		return err
+	}
+
+	opts := migrations.MigrationOptions{}
+
+	migrations.Register("000012_create_processing_runs_table", up, down, opts)
+}
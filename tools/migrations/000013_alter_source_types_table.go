@@ -0,0 +1,65 @@
+package main
+
+import (
+	"github.com/go-pg/pg/v9/orm"
+	"github.com/robinjoseph08/go-pg-migrations/v2"
+)
+
+func init() {
+	up := func(db orm.DB) error {
+		_, err := db.Exec(`ALTER TABLE source_types
+			ADD COLUMN enabled boolean not null default true;
+		`)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.Exec(`CREATE UNIQUE INDEX source_types_value_idx
+			ON source_types (value);
+		`)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.Exec(`CREATE FUNCTION notify_source_types_changed() RETURNS trigger AS $$
+			BEGIN
+			    PERFORM pg_notify('source_types_changed', '');
+			    RETURN NULL;
+			END;
+			$$ LANGUAGE plpgsql;
+		`)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.Exec(`CREATE TRIGGER source_types_changed
+			AFTER INSERT OR UPDATE OR DELETE ON source_types
+			FOR EACH STATEMENT EXECUTE PROCEDURE notify_source_types_changed();
+		`)
+		return err
+	}
+
+	down := func(db orm.DB) error {
+		_, err := db.Exec(`DROP TRIGGER IF EXISTS source_types_changed ON source_types;`)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.Exec(`DROP FUNCTION IF EXISTS notify_source_types_changed();`)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.Exec(`DROP INDEX IF EXISTS source_types_value_idx;`)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.Exec(`ALTER TABLE source_types DROP COLUMN IF EXISTS enabled;`)
+		return err
+	}
+
+	opts := migrations.MigrationOptions{}
+
+	migrations.Register("000013_alter_source_types_table", up, down, opts)
+}
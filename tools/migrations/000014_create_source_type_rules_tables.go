@@ -0,0 +1,74 @@
+package main
+
+import (
+	"github.com/go-pg/pg/v9/orm"
+	"github.com/robinjoseph08/go-pg-migrations/v2"
+)
+
+func init() {
+	up := func(db orm.DB) error {
+		_, err := db.Exec(`CREATE TABLE source_type_rules
+			(
+			    source_type_id int    primary key references source_types (id),
+			    allowed_states text[] not null default '{}'
+			);
+		`)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.Exec(`CREATE TABLE source_type_limits
+			(
+			    source_type_id   int            not null references source_types (id),
+			    currency         text           not null references currencies (code),
+			    min_amount       numeric(20, 8) not null default 0 CHECK (min_amount >= 0),
+			    max_amount       numeric(20, 8) not null default 0 CHECK (max_amount >= 0),
+			    max_daily_volume numeric(20, 8) not null default 0 CHECK (max_daily_volume >= 0),
+			    primary key (source_type_id, currency)
+			);
+		`)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.Exec(`INSERT INTO source_type_rules (source_type_id, allowed_states)
+			SELECT id, '{win,lost}' FROM source_types WHERE value = 'game';
+		`)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.Exec(`INSERT INTO source_type_limits (source_type_id, currency, max_amount)
+			SELECT source_types.id, currencies.code, 100
+			FROM source_types, currencies
+			WHERE source_types.value = 'server' AND currencies.code IN ('EUR', 'USD');
+		`)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.Exec(`CREATE INDEX payments_source_type_created_at_idx
+			ON payments (source_type, created_at);
+		`)
+		return err
+	}
+
+	down := func(db orm.DB) error {
+		_, err := db.Exec(`DROP INDEX IF EXISTS payments_source_type_created_at_idx;`)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.Exec(`DROP TABLE IF EXISTS source_type_limits;`)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.Exec(`DROP TABLE IF EXISTS source_type_rules;`)
+		return err
+	}
+
+	opts := migrations.MigrationOptions{}
+
+	migrations.Register("000014_create_source_type_rules_tables", up, down, opts)
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"github.com/go-pg/pg/v9/orm"
+	"github.com/robinjoseph08/go-pg-migrations/v2"
+)
+
+func init() {
+	up := func(db orm.DB) error {
+		_, err := db.Exec(`CREATE TABLE source_type_secrets
+			(
+			    id             serial primary key,
+			    created_at     timestamptz not null default now(),
+			    source_type_id int         not null references source_types (id),
+			    secret         text        not null,
+			    expires_at     timestamptz
+			);
+		`)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.Exec(`CREATE INDEX source_type_secrets_source_type_id_idx
+			ON source_type_secrets (source_type_id);
+		`)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.Exec(`CREATE TABLE request_nonces
+			(
+			    source_type_id int         not null references source_types (id),
+			    nonce          text        not null,
+			    created_at     timestamptz not null default now(),
+			    primary key (source_type_id, nonce)
+			);
+		`)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.Exec(`CREATE INDEX request_nonces_created_at_idx
+			ON request_nonces (created_at);
+		`)
+		return err
+	}
+
+	down := func(db orm.DB) error {
+		_, err := db.Exec(`DROP TABLE IF EXISTS request_nonces;`)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.Exec(`DROP TABLE IF EXISTS source_type_secrets;`)
+		return err
+	}
+
+	opts := migrations.MigrationOptions{}
+
+	migrations.Register("000015_create_source_type_secrets_table", up, down, opts)
+}
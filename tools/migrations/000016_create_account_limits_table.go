@@ -0,0 +1,46 @@
+package main
+
+import (
+	"github.com/go-pg/pg/v9/orm"
+	"github.com/robinjoseph08/go-pg-migrations/v2"
+)
+
+func init() {
+	up := func(db orm.DB) error {
+		_, err := db.Exec(`CREATE TABLE account_limits
+			(
+			    account_id     int            not null references accounts (id),
+			    currency       text           not null references currencies (code),
+			    kind           text           not null
+			        CHECK (kind IN ('daily_loss', 'weekly_loss', 'monthly_loss', 'max_stake')),
+			    amount         numeric(20, 8) not null default 0 CHECK (amount >= 0),
+			    pending_amount numeric(20, 8) CHECK (pending_amount >= 0),
+			    pending_from   timestamptz,
+			    updated_at     timestamptz    not null default now(),
+			    primary key (account_id, currency, kind)
+			);
+		`)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.Exec(`CREATE INDEX payments_account_losses_idx
+			ON payments (account_id, currency, created_at) WHERE state = 'lost';
+		`)
+		return err
+	}
+
+	down := func(db orm.DB) error {
+		_, err := db.Exec(`DROP INDEX IF EXISTS payments_account_losses_idx;`)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.Exec(`DROP TABLE IF EXISTS account_limits;`)
+		return err
+	}
+
+	opts := migrations.MigrationOptions{}
+
+	migrations.Register("000016_create_account_limits_table", up, down, opts)
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"github.com/go-pg/pg/v9/orm"
+	"github.com/robinjoseph08/go-pg-migrations/v2"
+)
+
+func init() {
+	up := func(db orm.DB) error {
+		_, err := db.Exec(`ALTER TABLE accounts
+			    ADD COLUMN excluded_from  timestamptz,
+			    ADD COLUMN excluded_until timestamptz
+			        CHECK (excluded_until IS NULL OR excluded_until > excluded_from);
+		`)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.Exec(`CREATE TABLE account_exclusion_changes
+			(
+			    id               serial primary key,
+			    created_at       timestamptz not null default now(),
+			    account_id       int         not null references accounts (id),
+			    excluded_from    timestamptz not null,
+			    excluded_until   timestamptz,
+			    actor_role       text        not null,
+			    actor_account_id int
+			);
+		`)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.Exec(`CREATE INDEX account_exclusion_changes_account_id_idx
+			ON account_exclusion_changes (account_id, id);
+		`)
+		return err
+	}
+
+	down := func(db orm.DB) error {
+		_, err := db.Exec(`DROP TABLE IF EXISTS account_exclusion_changes;`)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.Exec(`ALTER TABLE accounts
+			    DROP COLUMN IF EXISTS excluded_from,
+			    DROP COLUMN IF EXISTS excluded_until;
+		`)
+		return err
+	}
+
+	opts := migrations.MigrationOptions{}
+
+	migrations.Register("000017_add_accounts_exclusion", up, down, opts)
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"github.com/go-pg/pg/v9/orm"
+	"github.com/robinjoseph08/go-pg-migrations/v2"
+)
+
+func init() {
+	up := func(db orm.DB) error {
+		// legacy amounts were parsed as floats and may carry representation
+		// noise beyond 8 decimal places, which payments.Money can't scan
+		_, err := db.Exec(`ALTER TABLE payments
+			    ALTER COLUMN amount TYPE numeric(20, 8) USING round(amount, 8);
+		`)
+		return err
+	}
+
+	down := func(db orm.DB) error {
+		_, err := db.Exec(`ALTER TABLE payments ALTER COLUMN amount TYPE numeric;`)
+		return err
+	}
+
+	opts := migrations.MigrationOptions{}
+
+	migrations.Register("000018_alter_payments_amount_column", up, down, opts)
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"github.com/go-pg/pg/v9/orm"
+	"github.com/robinjoseph08/go-pg-migrations/v2"
+)
+
+// signatureOptOut leaves existing source types without secret accepting
+// unsigned requests until their first secret is issued. It's temporary,
+// set by MIGRATE_SIGNATURE_OPT_OUT for rollout of request signing.
+var signatureOptOut bool
+
+func init() {
+	up := func(db orm.DB) error {
+		_, err := db.Exec(`ALTER TABLE source_types
+			    ADD COLUMN signature_required boolean not null default true;
+		`)
+		if err != nil || !signatureOptOut {
+			return err
+		}
+
+		_, err = db.Exec(`UPDATE source_types st
+			SET signature_required = false
+			WHERE NOT EXISTS (
+			    SELECT 1 FROM source_type_secrets s
+			    WHERE s.source_type_id = st.id AND (s.expires_at IS NULL OR s.expires_at > now())
+			);
+		`)
+		return err
+	}
+
+	down := func(db orm.DB) error {
+		_, err := db.Exec(`ALTER TABLE source_types DROP COLUMN IF EXISTS signature_required;`)
+		return err
+	}
+
+	opts := migrations.MigrationOptions{}
+
+	migrations.Register("000019_add_source_types_signature_required", up, down, opts)
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"github.com/go-pg/pg/v9/orm"
+	"github.com/robinjoseph08/go-pg-migrations/v2"
+)
+
+func init() {
+	up := func(db orm.DB) error {
+		_, err := db.Exec(`ALTER TABLE payments ADD COLUMN applied_at timestamptz;`)
+		if err != nil {
+			return err
+		}
+
+		// retried payments are applied by their last accepted attempt, not when first received
+		_, err = db.Exec(`
+			UPDATE payments
+			SET applied_at = coalesce((
+			    SELECT max(payment_attempts.created_at)
+			    FROM payment_attempts
+			    WHERE payment_attempts.payment_id = payments.id AND payment_attempts.status = 'accepted'
+			), created_at)
+			WHERE status <> 'rejected';
+		`)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.Exec(`DROP INDEX IF EXISTS payments_source_type_created_at_idx;`)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.Exec(`CREATE INDEX payments_source_type_applied_at_idx
+			ON payments (source_type, applied_at);
+		`)
+		return err
+	}
+
+	down := func(db orm.DB) error {
+		_, err := db.Exec(`DROP INDEX IF EXISTS payments_source_type_applied_at_idx;`)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.Exec(`CREATE INDEX payments_source_type_created_at_idx
+			ON payments (source_type, created_at);
+		`)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.Exec(`ALTER TABLE payments DROP COLUMN IF EXISTS applied_at;`)
+		return err
+	}
+
+	opts := migrations.MigrationOptions{}
+
+	migrations.Register("000020_add_payments_applied_at_column", up, down, opts)
+}
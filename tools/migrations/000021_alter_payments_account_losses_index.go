@@ -0,0 +1,36 @@
+package main
+
+import (
+	"github.com/go-pg/pg/v9/orm"
+	"github.com/robinjoseph08/go-pg-migrations/v2"
+)
+
+func init() {
+	up := func(db orm.DB) error {
+		_, err := db.Exec(`DROP INDEX IF EXISTS payments_account_losses_idx;`)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.Exec(`CREATE INDEX payments_account_losses_idx
+			ON payments (account_id, currency, applied_at) WHERE state = 'lost';
+		`)
+		return err
+	}
+
+	down := func(db orm.DB) error {
+		_, err := db.Exec(`DROP INDEX IF EXISTS payments_account_losses_idx;`)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.Exec(`CREATE INDEX payments_account_losses_idx
+			ON payments (account_id, currency, created_at) WHERE state = 'lost';
+		`)
+		return err
+	}
+
+	opts := migrations.MigrationOptions{}
+
+	migrations.Register("000021_alter_payments_account_losses_index", up, down, opts)
+}
@@ -23,6 +23,8 @@ func main() {
 		logrus.Fatalf("failed to parse config: %v", err)
 	}
 
+	signatureOptOut = cfg.SignatureOptOut
+
 	db, err := database.Connect(ctx, &cfg.Database)
 	if err != nil {
 		logrus.Fatalf("failed to connect to database: %v", err)